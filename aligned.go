@@ -0,0 +1,111 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf8"
+)
+
+// AlignedWriter provides an interface for writing records as space-padded columns (like `column -t`).
+// Records are buffered until Flush is called so that column widths can be computed.
+// Successive calls to the Write method will append a value to the current record.
+// The EndOfRecord method tells when a line break is inserted.
+type AlignedWriter struct {
+	b      *bufio.Writer
+	rows   [][]string // buffered records
+	row    []string   // current record
+	widths []int      // max width (in runes) by column
+	err    error      // sticky error.
+
+	Padding int         // number of spaces between columns (2 by default)
+	Align   []Alignment // alignment by column (missing ones are AlignDefault which means left)
+}
+
+// NewAlignedWriter returns a new aligned columns writer.
+func NewAlignedWriter(w io.Writer) *AlignedWriter {
+	return &AlignedWriter{b: bufio.NewWriter(w), Padding: 2}
+}
+
+// Write appends value to the current record.
+func (w *AlignedWriter) Write(value []byte) bool {
+	return w.WriteString(string(value))
+}
+
+// WriteString appends value to the current record.
+func (w *AlignedWriter) WriteString(value string) bool {
+	if w.err != nil {
+		return false
+	}
+	col := len(w.row)
+	w.row = append(w.row, value)
+	n := utf8.RuneCountInString(value)
+	if col == len(w.widths) {
+		w.widths = append(w.widths, n)
+	} else if n > w.widths[col] {
+		w.widths[col] = n
+	}
+	return true
+}
+
+// EndOfRecord tells when a line break must be inserted.
+func (w *AlignedWriter) EndOfRecord() {
+	w.rows = append(w.rows, w.row)
+	w.row = nil
+}
+
+// Flush writes the buffered records with aligned columns and ensures the writer's buffer is flushed.
+func (w *AlignedWriter) Flush() {
+	if len(w.row) != 0 {
+		w.EndOfRecord()
+	}
+	for _, row := range w.rows {
+		pending := 0 // spaces not yet written (trailing spaces are omitted)
+		for i, value := range row {
+			if i > 0 {
+				pending += w.Padding
+			}
+			var align Alignment
+			if i < len(w.Align) {
+				align = w.Align[i]
+			}
+			pad := w.widths[i] - utf8.RuneCountInString(value)
+			var left int
+			switch align {
+			case AlignRight:
+				left = pad
+			case AlignCenter:
+				left = pad / 2
+			}
+			w.spaces(pending + left)
+			_, err := w.b.WriteString(value)
+			w.setErr(err)
+			pending = pad - left
+		}
+		w.setErr(w.b.WriteByte('\n'))
+	}
+	w.rows = w.rows[:0]
+	w.widths = w.widths[:0]
+	w.setErr(w.b.Flush())
+}
+
+// Err returns the first error that was encountered by the AlignedWriter.
+func (w *AlignedWriter) Err() error {
+	return w.err
+}
+
+func (w *AlignedWriter) spaces(n int) {
+	for ; n > 0; n-- {
+		w.setErr(w.b.WriteByte(' '))
+	}
+}
+
+// setErr records the first error encountered.
+func (w *AlignedWriter) setErr(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
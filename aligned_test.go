@@ -0,0 +1,54 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+var alignedTests = []struct {
+	Name   string
+	Align  []Alignment
+	Input  [][]string
+	Output string
+}{
+	{
+		Name:   "Simple",
+		Input:  [][]string{{"a", "bbb", "c"}, {"dddd", "e", "f"}},
+		Output: "a     bbb  c\ndddd  e    f\n",
+	},
+	{
+		Name:   "Ragged",
+		Input:  [][]string{{"a"}, {"bb", "c"}},
+		Output: "a\nbb  c\n",
+	},
+	{
+		Name:   "Align",
+		Align:  []Alignment{AlignRight, AlignCenter},
+		Input:  [][]string{{"1", "é", "x"}, {"100", "ééé", "y"}},
+		Output: "  1   é   x\n100  ééé  y\n",
+	},
+}
+
+func TestAlignedWriter(t *testing.T) {
+	for _, tt := range alignedTests {
+		b := &bytes.Buffer{}
+		w := NewAlignedWriter(b)
+		w.Align = tt.Align
+		for _, row := range tt.Input {
+			writeRow(w, row)
+		}
+		w.Flush()
+		if err := w.Err(); err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.Name, err)
+		}
+		if out := b.String(); out != tt.Output {
+			t.Errorf("%s: out=%q want %q", tt.Name, out, tt.Output)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+// RecordArena allocates retained records by chunks:
+// many records copied by CopyRecord share a single backing allocation
+// (instead of one allocation per field).
+type RecordArena struct {
+	chunkSize int      // minimal size of the byte chunks
+	buf       []byte   // current byte chunk
+	fields    [][]byte // current fields chunk
+}
+
+// NewRecordArena returns a new arena allocating byte chunks of at least chunkSize bytes
+// (4096 when chunkSize <= 0).
+func NewRecordArena(chunkSize int) *RecordArena {
+	if chunkSize <= 0 {
+		chunkSize = 4096
+	}
+	return &RecordArena{chunkSize: chunkSize}
+}
+
+// CopyRecord returns a copy of row allocated in dst.
+// The copy remains valid after row storage is reused (by a subsequent read).
+func CopyRecord(dst *RecordArena, row [][]byte) [][]byte {
+	size := 0
+	for _, field := range row {
+		size += len(field)
+	}
+	if cap(dst.buf)-len(dst.buf) < size {
+		n := dst.chunkSize
+		if size > n {
+			n = size
+		}
+		dst.buf = make([]byte, 0, n)
+	}
+	if cap(dst.fields)-len(dst.fields) < len(row) {
+		n := dst.chunkSize / 16
+		if len(row) > n {
+			n = len(row)
+		}
+		dst.fields = make([][]byte, 0, n)
+	}
+	start := len(dst.fields)
+	for _, field := range row {
+		i := len(dst.buf)
+		dst.buf = append(dst.buf, field...)
+		dst.fields = append(dst.fields, dst.buf[i:len(dst.buf):len(dst.buf)])
+	}
+	return dst.fields[start:len(dst.fields):len(dst.fields)]
+}
@@ -0,0 +1,47 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+type arenaSink struct {
+	arena *RecordArena
+	rows  [][][]byte
+}
+
+func (s *arenaSink) WriteFields(fields [][]byte) error {
+	s.rows = append(s.rows, CopyRecord(s.arena, fields))
+	return nil
+}
+
+func TestCopyRecord(t *testing.T) {
+	sink := &arenaSink{arena: NewRecordArena(8)}
+	if _, err := Copy(sink, DefaultReader(strings.NewReader("a,b\nc,\ndefghijkl,m\n"))); err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	for _, row := range sink.rows {
+		var values []string
+		for _, field := range row {
+			values = append(values, string(field))
+		}
+		rows = append(rows, values)
+	}
+	if want := [][]string{{"a", "b"}, {"c", ""}, {"defghijkl", "m"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %q; want %q", rows, want)
+	}
+
+	arena := NewRecordArena(0)
+	row := [][]byte{[]byte("abc"), []byte("de")}
+	if n := testing.AllocsPerRun(100, func() { CopyRecord(arena, row) }); n > 0.1 {
+		t.Errorf("got %f allocs per record; want ~0", n)
+	}
+}
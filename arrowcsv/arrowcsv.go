@@ -0,0 +1,255 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package arrowcsv bridges yacr readers/writers and Apache Arrow record batches.
+package arrowcsv
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/gwenn/yacr"
+)
+
+// Reader reads CSV records into Arrow record batches according to a schema.
+// Fields are appended directly to the column builders (no intermediate [][]string).
+// Empty fields are converted to nulls when the schema field is nullable.
+// It implements array.RecordReader.
+type Reader struct {
+	refs   int64
+	r      *yacr.Reader
+	schema *arrow.Schema
+	b      *array.RecordBuilder
+	batch  int // max number of rows by batch
+	cur    arrow.RecordBatch
+	recno  int // number of records read
+	err    error
+
+	mem    memory.Allocator
+	values []value         // converted fields of the current record (see convert)
+	checks []array.Builder // builders only used to validate fields of other types (see convert)
+}
+
+// value is a converted field.
+type value struct {
+	null bool
+	i    int64
+	f    float64
+	t    bool
+}
+
+// NewReader returns a new Arrow record batches reader.
+// The CSV reader must be positioned on the first data record (see yacr.Reader.SkipRecords).
+func NewReader(r *yacr.Reader, schema *arrow.Schema, batchSize int, mem memory.Allocator) *Reader {
+	if mem == nil {
+		mem = memory.DefaultAllocator
+	}
+	if batchSize <= 0 {
+		batchSize = 1024
+	}
+	nf := len(schema.Fields())
+	return &Reader{refs: 1, r: r, schema: schema, b: array.NewRecordBuilder(mem, schema), batch: batchSize,
+		mem: mem, values: make([]value, nf), checks: make([]array.Builder, nf)}
+}
+
+// Retain increases the reference count by 1.
+func (r *Reader) Retain() {
+	atomic.AddInt64(&r.refs, 1)
+}
+
+// Release decreases the reference count by 1.
+// When the reference count goes to zero, the memory is freed.
+func (r *Reader) Release() {
+	if atomic.AddInt64(&r.refs, -1) == 0 {
+		if r.cur != nil {
+			r.cur.Release()
+			r.cur = nil
+		}
+		r.b.Release()
+		for _, c := range r.checks {
+			if c != nil {
+				c.Release()
+			}
+		}
+	}
+}
+
+// Schema returns the schema of the record batches.
+func (r *Reader) Schema() *arrow.Schema {
+	return r.schema
+}
+
+// Next reads the next batch of records.
+// It returns false at the end of the input or when an error occurred (see Err).
+func (r *Reader) Next() bool {
+	if r.cur != nil {
+		r.cur.Release()
+		r.cur = nil
+	}
+	if r.err != nil {
+		return false
+	}
+	n := 0
+	for ; n < r.batch; n++ {
+		ok, err := r.next()
+		if err != nil {
+			r.err = err
+			break
+		} else if !ok {
+			break
+		}
+	}
+	for _, c := range r.checks {
+		if c != nil {
+			c.NewArray().Release()
+		}
+	}
+	if n == 0 {
+		return false
+	}
+	r.cur = r.b.NewRecordBatch()
+	return r.err == nil
+}
+
+// RecordBatch returns the current batch.
+// It is valid until the next call to Next.
+func (r *Reader) RecordBatch() arrow.RecordBatch {
+	return r.cur
+}
+
+// Record returns the current batch.
+//
+// Deprecated: Use RecordBatch instead.
+func (r *Reader) Record() arrow.Record {
+	return r.cur
+}
+
+// Err returns the first error that was encountered by the Reader.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// next appends one record to the builders.
+// The record is entirely validated before being appended so that all builders keep the same length.
+func (r *Reader) next() (bool, error) {
+	fields, err := r.r.ReadRecord()
+	if err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	r.recno++
+	if nf := len(r.schema.Fields()); len(fields) != nf {
+		return false, fmt.Errorf("record %d: got %d field(s), want %d", r.recno, len(fields), nf)
+	}
+	for i, v := range fields {
+		if err = r.convert(i, v); err != nil {
+			return false, fmt.Errorf("record %d, column %q: %v", r.recno, r.schema.Field(i).Name, err)
+		}
+	}
+	for i, v := range fields {
+		r.append(i, v)
+	}
+	return true, nil
+}
+
+// convert validates the i-th field v, storing its value in r.values[i].
+func (r *Reader) convert(i int, v []byte) error {
+	fb := r.b.Field(i)
+	val := &r.values[i]
+	val.null = len(v) == 0 && r.schema.Field(i).Nullable
+	if val.null {
+		return nil
+	}
+	var err error
+	switch fb.(type) {
+	case *array.StringBuilder, *array.BinaryBuilder:
+	case *array.Int64Builder:
+		val.i, err = strconv.ParseInt(string(v), 10, 64)
+	case *array.Float64Builder:
+		val.f, err = strconv.ParseFloat(string(v), 64)
+	case *array.BooleanBuilder:
+		val.t, err = strconv.ParseBool(string(v))
+	default:
+		if r.checks[i] == nil {
+			r.checks[i] = array.NewBuilder(r.mem, fb.Type())
+		}
+		err = r.checks[i].AppendValueFromString(string(v))
+	}
+	return err
+}
+
+// append appends the i-th field v converted by convert.
+func (r *Reader) append(i int, v []byte) {
+	fb := r.b.Field(i)
+	val := r.values[i]
+	if val.null {
+		fb.AppendNull()
+		return
+	}
+	switch b := fb.(type) {
+	case *array.StringBuilder:
+		b.Append(string(v))
+	case *array.BinaryBuilder:
+		b.Append(v)
+	case *array.Int64Builder:
+		b.Append(val.i)
+	case *array.Float64Builder:
+		b.Append(val.f)
+	case *array.BooleanBuilder:
+		b.Append(val.t)
+	default:
+		_ = fb.AppendValueFromString(string(v)) // already validated
+	}
+}
+
+// WriteHeader writes schema's field names as a CSV record.
+func WriteHeader(w *yacr.Writer, schema *arrow.Schema) error {
+	for _, f := range schema.Fields() {
+		if !w.WriteString(f.Name) {
+			break
+		}
+	}
+	w.EndOfRecord()
+	return w.Err()
+}
+
+// WriteRecordBatch writes the rows of rec as CSV records.
+// Nulls are written as empty fields.
+func WriteRecordBatch(w *yacr.Writer, rec arrow.RecordBatch) error {
+	cols := rec.Columns()
+	var buf []byte
+	for i := 0; i < int(rec.NumRows()); i++ {
+		for _, col := range cols {
+			if col.IsNull(i) {
+				w.Write(buf[:0])
+				continue
+			}
+			switch a := col.(type) {
+			case *array.String:
+				w.WriteString(a.Value(i))
+			case *array.Binary:
+				w.Write(a.Value(i))
+			case *array.Int64:
+				buf = strconv.AppendInt(buf[:0], a.Value(i), 10)
+				w.Write(buf)
+			case *array.Float64:
+				buf = strconv.AppendFloat(buf[:0], a.Value(i), 'f', -1, 64)
+				w.Write(buf)
+			default:
+				w.WriteString(col.ValueStr(i))
+			}
+		}
+		w.EndOfRecord()
+		if err := w.Err(); err != nil {
+			return err
+		}
+	}
+	return w.Err()
+}
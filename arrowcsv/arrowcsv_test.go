@@ -0,0 +1,96 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package arrowcsv_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/gwenn/yacr"
+	. "github.com/gwenn/yacr/arrowcsv"
+)
+
+func TestRoundTrip(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "name", Type: arrow.BinaryTypes.String},
+		{Name: "count", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		{Name: "price", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "ok", Type: arrow.FixedWidthTypes.Boolean},
+		{Name: "day", Type: arrow.FixedWidthTypes.Date32},
+	}, nil)
+	input := "name,count,price,ok,day\na,1,3.14,true,2020-01-02\n\n\"b,c\",,0.5,false,1970-01-01\nd,3,1,true,2000-12-31\n"
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	r := yacr.DefaultReader(strings.NewReader(input))
+	if err := r.SkipRecords(1); err != nil {
+		t.Fatal(err)
+	}
+	ar := NewReader(r, schema, 2, mem)
+	defer ar.Release()
+
+	b := &bytes.Buffer{}
+	w := yacr.DefaultWriter(b)
+	if err := WriteHeader(w, schema); err != nil {
+		t.Fatal(err)
+	}
+	batches := 0
+	for ar.Next() {
+		batches++
+		if err := WriteRecordBatch(w, ar.RecordBatch()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ar.Err(); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	if batches != 2 {
+		t.Errorf("got %d batches; want %d", batches, 2)
+	}
+	want := strings.Replace(input, "\n\n", "\n", 1)
+	if out := b.String(); out != want {
+		t.Errorf("got %q; want %q", out, want)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "count", Type: arrow.PrimitiveTypes.Int64},
+	}, nil)
+	for _, input := range []string{"1\nx\n", "1\n2,3\n"} {
+		ar := NewReader(yacr.DefaultReader(strings.NewReader(input)), schema, 10, nil)
+		if ar.Next() {
+			t.Errorf("%q: unexpected batch", input)
+		}
+		if ar.Err() == nil {
+			t.Errorf("%q: error expected", input)
+		}
+		ar.Release()
+	}
+	// partial record: fields already converted must not be appended
+	schema = arrow.NewSchema([]arrow.Field{
+		{Name: "a", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "b", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "day", Type: arrow.FixedWidthTypes.Date32},
+	}, nil)
+	for _, input := range []string{"1,2,2020-01-02\n3\n", "1,2,2020-01-02\n3,x,2020-01-02\n", "1,2,2020-01-02\n3,4,x\n"} {
+		mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+		ar := NewReader(yacr.DefaultReader(strings.NewReader(input)), schema, 10, mem)
+		if ar.Next() {
+			t.Errorf("%q: unexpected batch", input)
+		}
+		if ar.Err() == nil {
+			t.Errorf("%q: error expected", input)
+		} else if rows := ar.RecordBatch().NumRows(); rows != 1 {
+			t.Errorf("%q: got %d rows; want %d", input, rows, 1)
+		}
+		ar.Release()
+		mem.AssertSize(t, 0)
+	}
+}
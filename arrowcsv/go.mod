@@ -0,0 +1,18 @@
+module github.com/gwenn/yacr/arrowcsv
+
+go 1.23.0
+
+require github.com/gwenn/yacr v0.0.0
+
+require (
+	github.com/apache/arrow-go/v18 v18.4.1
+	github.com/goccy/go-json v0.10.5 // indirect
+	github.com/google/flatbuffers v25.2.10+incompatible // indirect
+	github.com/klauspost/cpuid/v2 v2.3.0 // indirect
+	github.com/zeebo/xxh3 v1.0.2 // indirect
+	golang.org/x/exp v0.0.0-20250408133849-7e4ce0ab07d0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/xerrors v0.0.0-20240903120638-7835f813f4da // indirect
+)
+
+replace github.com/gwenn/yacr => ../
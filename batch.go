@@ -0,0 +1,73 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import "io"
+
+// recordStorage is the reusable storage of one record.
+type recordStorage struct {
+	buf    []byte   // fields content
+	fields [][]byte // fields (referencing buf)
+}
+
+// ReadRecord returns the fields of the next record (empty lines are skipped).
+// The returned slice and fields are reused by the next call to ReadRecord
+// (so reading records of similar sizes does not allocate), use CopyRecord to retain them.
+// It returns (nil, io.EOF) when there is no more record.
+func (s *Reader) ReadRecord() ([][]byte, error) {
+	return s.readInto(&s.single)
+}
+
+// ReadBatch returns at most max records (less only at the end of the input or on error).
+// The returned records are reused by the next call to ReadBatch
+// (so reading batches of similar sizes does not allocate), use CopyRecord to retain them.
+// It returns (nil, io.EOF) when there is no more record.
+func (s *Reader) ReadBatch(max int) ([][][]byte, error) {
+	s.records = s.records[:0]
+	for i := 0; i < max; i++ {
+		if i == len(s.storages) {
+			s.storages = append(s.storages, recordStorage{})
+		}
+		fields, err := s.readInto(&s.storages[i])
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return s.records, err
+		}
+		s.records = append(s.records, fields)
+	}
+	if len(s.records) == 0 && max > 0 {
+		return nil, io.EOF
+	}
+	return s.records, nil
+}
+
+// ForEach calls fn with the fields of each record (empty lines and line comments are skipped)
+// until the end of the input, a parsing error or an error returned by fn (which is returned as is).
+// Like ReadRecord, fields are only valid during the call to fn.
+func (s *Reader) ForEach(fn func(fields [][]byte) error) error {
+	for {
+		fields, err := s.readInto(&s.single)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		} else if err = fn(fields); err != nil {
+			return err
+		}
+	}
+}
+
+// readInto reads the next record reusing rs storage.
+func (s *Reader) readInto(rs *recordStorage) ([][]byte, error) {
+	var err error
+	rs.buf, rs.fields, err = s.readRecord(rs.buf, rs.fields)
+	if err != nil {
+		return rs.fields, err
+	} else if rs.fields == nil {
+		return nil, io.EOF
+	}
+	return rs.fields, nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestReadRecord(t *testing.T) {
+	r := DefaultReader(strings.NewReader(strings.Repeat("abc,\"d,e\",f\n\n", 1000)))
+	record, err := r.ReadRecord()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(record) != 3 || string(record[1]) != "d,e" {
+		t.Errorf("unexpected record: %q", record)
+	}
+	if n := testing.AllocsPerRun(100, func() { record, err = r.ReadRecord() }); n != 0 {
+		t.Errorf("got %f allocs per record; want 0", n)
+	}
+	if err != nil || len(record) != 3 || string(record[2]) != "f" {
+		t.Errorf("unexpected record: %q (%v)", record, err)
+	}
+	for err == nil {
+		record, err = r.ReadRecord()
+	}
+	if err != io.EOF || record != nil {
+		t.Errorf("got (%q, %v); want (nil, EOF)", record, err)
+	}
+}
+
+func TestReadBatch(t *testing.T) {
+	r := DefaultReader(strings.NewReader(strings.Repeat("a,b\nc,d,e\n", 501)))
+	batch, err := r.ReadBatch(10)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(batch) != 10 || string(batch[9][2]) != "e" {
+		t.Errorf("unexpected batch: %q", batch)
+	}
+	if n := testing.AllocsPerRun(50, func() { batch, err = r.ReadBatch(10) }); n != 0 {
+		t.Errorf("got %f allocs per batch; want 0", n)
+	}
+	total := 10 + 51*10 // AllocsPerRun warms up once
+	for {
+		if batch, err = r.ReadBatch(10); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		total += len(batch)
+	}
+	if total != 1002 {
+		t.Errorf("got %d records; want %d", total, 1002)
+	}
+}
+
+func TestReadColumns(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,b\n\nc,\"d\nd\",e\nf\n" + strings.Repeat("1,2,3\n", 200)))
+	cols, err := r.ReadColumns(3)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(cols) != 3 || cols.Len() != 3 {
+		t.Fatalf("got %d columns of %d values; want 3 columns of 3 values", len(cols), cols.Len())
+	}
+	var got []string
+	for i := range cols {
+		for j := 0; j < cols[i].Len(); j++ {
+			got = append(got, string(cols[i].Value(j)))
+		}
+	}
+	if want := "a,c,f,b,d\nd,,,e,"; strings.Join(got, ",") != want {
+		t.Errorf("got %q; want %q", strings.Join(got, ","), want)
+	}
+	if n := testing.AllocsPerRun(50, func() { cols, err = r.ReadColumns(3) }); n != 0 {
+		t.Errorf("got %f allocs per batch; want 0", n)
+	}
+	total := 3 + 51*3 // AllocsPerRun warms up once
+	for {
+		if cols, err = r.ReadColumns(3); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		total += cols.Len()
+	}
+	if total != 203 {
+		t.Errorf("got %d records; want %d", total, 203)
+	}
+}
+
+func TestForEach(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,b\n\nc,\"d\nd\"\ne\n"))
+	var got []string
+	err := r.ForEach(func(fields [][]byte) error {
+		for _, field := range fields {
+			got = append(got, string(field))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if want := "a,b,c,d\nd,e"; strings.Join(got, ",") != want {
+		t.Errorf("got %q; want %q", strings.Join(got, ","), want)
+	}
+	stop := errors.New("stop")
+	n := 0
+	r = DefaultReader(strings.NewReader("a\nb\nc\n"))
+	if err = r.ForEach(func(fields [][]byte) error {
+		if n++; n == 2 {
+			return stop
+		}
+		return nil
+	}); err != stop || n != 2 {
+		t.Errorf("got (%v, %d); want (%v, 2)", err, n, stop)
+	}
+}
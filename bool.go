@@ -0,0 +1,47 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// BoolTokens lists the text representations of booleans (like "Y"/"N", "1"/"0" or "yes"/"no").
+// Tokens are matched case-insensitively when reading and the first ones are used when writing.
+type BoolTokens struct {
+	True  []string
+	False []string
+}
+
+// parse converts text to a boolean (using strconv.ParseBool when t is nil).
+func (t *BoolTokens) parse(text string) (bool, error) {
+	if t == nil {
+		return strconv.ParseBool(text)
+	}
+	for _, token := range t.True {
+		if strings.EqualFold(token, text) {
+			return true, nil
+		}
+	}
+	for _, token := range t.False {
+		if strings.EqualFold(token, text) {
+			return false, nil
+		}
+	}
+	return false, &strconv.NumError{Func: "ParseBool", Num: text, Err: strconv.ErrSyntax}
+}
+
+// format converts b to text (using strconv.FormatBool when t is nil or has no token).
+func (t *BoolTokens) format(b bool) string {
+	if t != nil {
+		if b && len(t.True) > 0 {
+			return t.True[0]
+		} else if !b && len(t.False) > 0 {
+			return t.False[0]
+		}
+	}
+	return strconv.FormatBool(b)
+}
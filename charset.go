@@ -0,0 +1,318 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Charset identifies a character encoding supported by the transcoders.
+type Charset int
+
+// Supported charsets
+const (
+	UTF8   Charset = iota
+	Latin1         // ISO-8859-1
+	UTF16LE
+	UTF16BE
+)
+
+var charsetNames = []string{"utf-8", "iso-8859-1", "utf-16le", "utf-16be"}
+
+func (c Charset) String() string {
+	if c < 0 || int(c) >= len(charsetNames) {
+		return fmt.Sprintf("Charset(%d)", int(c))
+	}
+	return charsetNames[c]
+}
+
+// ParseCharset returns the charset matching name (case insensitive).
+func ParseCharset(name string) (Charset, error) {
+	switch strings.ToLower(name) {
+	case "utf-8", "utf8":
+		return UTF8, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1":
+		return Latin1, nil
+	case "utf-16le", "utf16le":
+		return UTF16LE, nil
+	case "utf-16be", "utf16be":
+		return UTF16BE, nil
+	}
+	return UTF8, fmt.Errorf("yacr: unsupported charset: %s", name)
+}
+
+// NewDecoder returns a reader converting text from c to UTF-8.
+// A leading byte order mark is removed.
+func (c Charset) NewDecoder(r io.Reader) io.Reader {
+	return &decoder{c: c, r: bufio.NewReader(r), bom: true}
+}
+
+// NewEncoder returns a writer converting UTF-8 text to c.
+// Runes that cannot be represented in c are reported as errors.
+// Close must be called after the last Write: it reports a rune left incomplete
+// (but does not close w).
+func (c Charset) NewEncoder(w io.Writer) io.WriteCloser {
+	if c == UTF8 {
+		return nopCloser{w}
+	}
+	return &encoder{c: c, w: w}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// charsetSniffLen is the number of bytes inspected by Reader.GuessCharset.
+const charsetSniffLen = 4096
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// DetectCharset guesses the encoding of data (the first few KB of an input):
+// a byte order mark wins, then UTF-16 is recognized by null bytes at odd (little endian) or even (big endian) offsets,
+// then valid UTF-8 is assumed (a rune truncated by the end of data is ignored), Latin1 otherwise.
+func DetectCharset(data []byte) Charset {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return UTF8
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return UTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return UTF16BE
+	}
+	var even, odd int // null bytes by offset parity
+	n := len(data) &^ 1
+	for i := 0; i < n; i += 2 {
+		if data[i] == 0 {
+			even++
+		}
+		if data[i+1] == 0 {
+			odd++
+		}
+	}
+	// mostly ASCII text (at least a quarter of the code units) with nulls mostly on one side
+	if odd*8 >= n && even*2 < odd {
+		return UTF16LE
+	} else if even*8 >= n && odd*2 < even {
+		return UTF16BE
+	}
+	if validUTF8Prefix(data) {
+		return UTF8
+	}
+	return Latin1
+}
+
+// validUTF8Prefix tells if data is valid UTF-8, ignoring a rune truncated at its end.
+func validUTF8Prefix(data []byte) bool {
+	for i := len(data) - 1; i >= 0 && i > len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				data = data[:i]
+			}
+			break
+		}
+	}
+	return utf8.Valid(data)
+}
+
+// GuessCharset detects the encoding of the input from its first 4 KB (see DetectCharset)
+// and transparently converts it to UTF-8 (a leading byte order mark is removed).
+// For multi-file Readers, the encoding of each file is detected.
+// It must be called before the first Scan. The detected encoding is returned by Charset.
+// Progress then reports decoded bytes.
+func (s *Reader) GuessCharset() error {
+	if s.offset > 0 || s.recno > 0 || s.sources > 0 {
+		return errors.New("yacr: GuessCharset called after the first Scan")
+	}
+	s.guessCharset = true
+	if s.next != nil { // multi-file Reader: see nextSource
+		return nil
+	}
+	s.Scanner = bufio.NewScanner(s.sniffCharset(s.input))
+	if s.bufSize > 0 {
+		s.Buffer(make([]byte, 0, s.bufSize), s.maxSize)
+	} else {
+		s.Buffer(nil, s.maxSize)
+	}
+	s.Split(s.ScanField)
+	return nil
+}
+
+// Charset returns the encoding detected by GuessCharset (of the current file for multi-file Readers),
+// UTF8 when not guessed.
+func (s *Reader) Charset() Charset {
+	return s.charset
+}
+
+// sniffCharset detects the encoding of r and returns a reader converting it to UTF-8.
+func (s *Reader) sniffCharset(r io.Reader) io.Reader {
+	br := bufio.NewReaderSize(r, charsetSniffLen)
+	data, _ := br.Peek(charsetSniffLen) // errors are reported by the next Read
+	s.charset = DetectCharset(data)
+	if s.charset != UTF8 {
+		return s.charset.NewDecoder(br)
+	} else if bytes.HasPrefix(data, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+	return br
+}
+
+type decoder struct {
+	c   Charset
+	r   *bufio.Reader
+	bom bool   // true until the first rune has been read
+	buf []byte // decoded bytes not yet returned
+	err error
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	for len(d.buf) < len(p) && d.err == nil {
+		var r rune
+		if r, d.err = d.next(); d.err != nil {
+			break
+		}
+		if d.bom {
+			d.bom = false
+			if r == '\uFEFF' {
+				continue
+			}
+		}
+		var b [utf8.UTFMax]byte
+		d.buf = append(d.buf, b[:utf8.EncodeRune(b[:], r)]...)
+		if d.r.Buffered() == 0 {
+			break // avoid blocking
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[:copy(d.buf, d.buf[n:])]
+	if n == 0 && d.err != nil {
+		return 0, d.err
+	}
+	return n, nil
+}
+
+func (d *decoder) next() (rune, error) {
+	switch d.c {
+	case Latin1:
+		b, err := d.r.ReadByte()
+		return rune(b), err
+	case UTF16LE, UTF16BE:
+		r1, err := d.readUint16()
+		if err != nil {
+			return 0, err
+		}
+		if !utf16.IsSurrogate(r1) {
+			return r1, nil
+		} else if r1 >= 0xDC00 { // unpaired low surrogate
+			return utf8.RuneError, nil
+		}
+		b, err := d.r.Peek(2)
+		if len(b) < 2 {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		r2 := d.unit(b[0], b[1])
+		if r2 < 0xDC00 || r2 > 0xDFFF { // unpaired high surrogate: r2 is decoded by the next call
+			return utf8.RuneError, nil
+		}
+		d.r.Discard(2)
+		return utf16.DecodeRune(r1, r2), nil
+	default:
+		r, _, err := d.r.ReadRune()
+		return r, err
+	}
+}
+
+func (d *decoder) readUint16() (rune, error) {
+	b0, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	b1, err := d.r.ReadByte()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, err
+	}
+	return d.unit(b0, b1), nil
+}
+
+// unit returns the UTF-16 code unit encoded by b0 and b1.
+func (d *decoder) unit(b0, b1 byte) rune {
+	if d.c == UTF16BE {
+		return rune(b0)<<8 | rune(b1)
+	}
+	return rune(b1)<<8 | rune(b0)
+}
+
+type encoder struct {
+	c    Charset
+	w    io.Writer
+	tail []byte // incomplete rune
+	buf  []byte
+}
+
+func (e *encoder) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(e.tail) > 0 {
+		p = append(e.tail, p...)
+		e.tail = nil
+	}
+	e.buf = e.buf[:0]
+	for len(p) > 0 {
+		if !utf8.FullRune(p) {
+			e.tail = append(e.tail, p...)
+			break
+		}
+		r, size := utf8.DecodeRune(p)
+		p = p[size:]
+		switch e.c {
+		case Latin1:
+			if r > 0xFF {
+				return 0, fmt.Errorf("yacr: %q cannot be encoded in %s", r, e.c)
+			}
+			e.buf = append(e.buf, byte(r))
+		default:
+			r1, r2 := utf16.EncodeRune(r)
+			if r1 == utf8.RuneError {
+				e.buf = e.appendUint16(e.buf, r)
+			} else {
+				e.buf = e.appendUint16(e.appendUint16(e.buf, r1), r2)
+			}
+		}
+	}
+	if _, err := e.w.Write(e.buf); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Close reports an incomplete trailing rune.
+func (e *encoder) Close() error {
+	if len(e.tail) > 0 {
+		return fmt.Errorf("yacr: incomplete UTF-8 sequence %q cannot be encoded in %s", e.tail, e.c)
+	}
+	return nil
+}
+
+func (e *encoder) appendUint16(b []byte, r rune) []byte {
+	if e.c == UTF16BE {
+		return append(b, byte(r>>8), byte(r))
+	}
+	return append(b, byte(r), byte(r>>8))
+}
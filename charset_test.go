@@ -0,0 +1,141 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	. "github.com/gwenn/yacr"
+)
+
+var charsetTests = []struct {
+	Charset Charset
+	Encoded string
+	Decoded string
+}{
+	{UTF8, "a,é\n", "a,é\n"},
+	{Latin1, "a,\xe9\n", "a,é\n"},
+	{UTF16LE, "a\x00,\x00\xe9\x00\n\x00=\xd8\x00\xde", "a,é\n😀"},
+	{UTF16BE, "\x00a\x00,\x00\xe9\x00\n\xd8=\xde\x00", "a,é\n😀"},
+}
+
+func TestCharset(t *testing.T) {
+	for _, tt := range charsetTests {
+		b, err := ioutil.ReadAll(tt.Charset.NewDecoder(bytes.NewBufferString(tt.Encoded)))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.Charset, err)
+		} else if string(b) != tt.Decoded {
+			t.Errorf("%s: got %q; want %q", tt.Charset, b, tt.Decoded)
+		}
+		out := &bytes.Buffer{}
+		w := tt.Charset.NewEncoder(out)
+		for i := 0; i < len(tt.Decoded); i++ { // byte by byte
+			if _, err = w.Write([]byte{tt.Decoded[i]}); err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.Charset, err)
+			}
+		}
+		if err = w.Close(); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.Charset, err)
+		}
+		if out.String() != tt.Encoded {
+			t.Errorf("%s: got %q; want %q", tt.Charset, out.String(), tt.Encoded)
+		}
+	}
+	w := UTF16LE.NewEncoder(ioutil.Discard)
+	if _, err := w.Write([]byte("a\xc3")); err != nil {
+		t.Fatal(err)
+	} else if err = w.Close(); err == nil {
+		t.Error("error expected for an incomplete rune")
+	}
+}
+
+func TestCharsetUnpairedSurrogate(t *testing.T) {
+	for input, want := range map[string]string{"\x00\xd8A\x00B\x00": "\uFFFDAB", "\x00\xdcA\x00": "\uFFFDA"} {
+		b, err := ioutil.ReadAll(UTF16LE.NewDecoder(bytes.NewBufferString(input)))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", input, err)
+		} else if string(b) != want {
+			t.Errorf("%q: got %q; want %q", input, b, want)
+		}
+	}
+}
+
+func TestCharsetNoBlocking(t *testing.T) {
+	for _, c := range []Charset{Latin1, UTF16LE} {
+		r := c.NewDecoder(iotest.OneByteReader(strings.NewReader("a\x00,\x00b\x00\n\x00")))
+		p := make([]byte, 16)
+		if n, err := r.Read(p); err != nil || n != 1 {
+			t.Errorf("%s: got %d, %v; want one rune", c, n, err)
+		}
+	}
+}
+
+func TestCharsetBOM(t *testing.T) {
+	b, err := ioutil.ReadAll(UTF16LE.NewDecoder(bytes.NewBufferString("\xff\xfea\x00")))
+	if err != nil {
+		t.Fatal(err)
+	} else if string(b) != "a" {
+		t.Errorf("got %q; want %q", b, "a")
+	}
+	if c, err := ParseCharset("Latin1"); err != nil || c != Latin1 {
+		t.Errorf("got %v, %v; want %v", c, err, Latin1)
+	}
+}
+
+var detectCharsetTests = []struct {
+	Data    string
+	Charset Charset
+}{
+	{"", UTF8},
+	{"a,b\n", UTF8},
+	{"\xef\xbb\xbfa,\xe9\n", UTF8}, // BOM wins
+	{"a,\xc3", UTF8},               // truncated rune
+	{"a,\xe9\n", Latin1},           // high byte
+	{"\xff\xfe\x00a", UTF16LE},     // BOM
+	{"\xfe\xff\xe9\x00", UTF16BE},  // BOM
+	{"a\x00,\x00\xe9\x00\n\x00", UTF16LE},
+	{"\x00a\x00,\x00\xe9\x00\n", UTF16BE},
+}
+
+func TestDetectCharset(t *testing.T) {
+	for _, tt := range detectCharsetTests {
+		if c := DetectCharset([]byte(tt.Data)); c != tt.Charset {
+			t.Errorf("%q: got %s; want %s", tt.Data, c, tt.Charset)
+		}
+	}
+}
+
+func TestGuessCharset(t *testing.T) {
+	for _, tt := range charsetTests {
+		r := DefaultReader(bytes.NewBufferString(tt.Encoded))
+		if err := r.GuessCharset(); err != nil {
+			t.Fatal(err)
+		}
+		record, err := r.Record()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.Charset, err)
+		} else if r.Charset() != tt.Charset {
+			t.Errorf("%s: got %s", tt.Charset, r.Charset())
+		} else if record[1] != "é" {
+			t.Errorf("%s: got %q", tt.Charset, record)
+		}
+		if err = r.GuessCharset(); err == nil {
+			t.Errorf("%s: error expected after Scan", tt.Charset)
+		}
+	}
+	r := DefaultReader(bytes.NewBufferString("\xef\xbb\xbfa,b\n"))
+	r.GuessCharset()
+	if record, err := r.Record(); err != nil || record[0] != "a" {
+		t.Errorf("got %q, %v; want BOM removed", record, err)
+	}
+	c := r.CloneFor(bytes.NewBufferString("a,\xe9\n"))
+	if record, err := c.Record(); err != nil || c.Charset() != Latin1 || record[1] != "é" {
+		t.Errorf("got %q, %v, %s; want Latin1 detected by clone", record, err, c.Charset())
+	}
+}
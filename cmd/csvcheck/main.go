@@ -0,0 +1,85 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command csvcheck reports structural and schema violations found in CSV files.
+// It exits with a non-zero status when a violation is found.
+//
+// Usage:
+//
+//	csvcheck [flags] [file ...]
+//
+// Files are checked in sequence (standard input when none is specified),
+// gzip/bzip2 compressed files are transparently decompressed (based on their extension).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/gwenn/yacr"
+)
+
+var (
+	sep    = flag.String("sep", ",", "separator")
+	quoted = flag.Bool("quoted", true, "values may be quoted")
+	lazy   = flag.Bool("lazy", false, "quoted values may contain unescaped quotes")
+	header = flag.Bool("header", false, "first record is a header")
+	schema = flag.String("schema", "", "expected columns (for example: \"id:int,name,price:float?\")")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("csvcheck: ")
+	flag.Parse()
+	if *sep == `\t` {
+		*sep = "\t"
+	}
+	if len(*sep) != 1 {
+		log.Fatalf("invalid separator: %q", *sep)
+	}
+	l := &yacr.Linter{Header: *header}
+	if *schema != "" {
+		var err error
+		if l.Schema, err = yacr.ParseSchema(*schema); err != nil {
+			log.Fatal(err)
+		}
+	}
+	ok := true
+	if flag.NArg() == 0 {
+		ok = check(l, "<stdin>", os.Stdin)
+	}
+	for _, path := range flag.Args() {
+		f, err := yacr.Zopen(path)
+		if err != nil {
+			log.Print(err)
+			ok = false
+			continue
+		}
+		if !check(l, path, f) {
+			ok = false
+		}
+		_ = f.Close()
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// check reports violations found in rd and returns true when there is none.
+func check(l *yacr.Linter, name string, rd io.Reader) bool {
+	r := yacr.NewReader(rd, (*sep)[0], *quoted, false)
+	r.Lazy = *lazy
+	violations, err := l.Lint(r)
+	for _, v := range violations {
+		if v.Column == 0 {
+			fmt.Printf("%s:%d: record %d: %s\n", name, v.Line, v.Record, v.Msg)
+		} else {
+			fmt.Printf("%s:%d:%d: record %d: %s\n", name, v.Line, v.Column, v.Record, v.Msg)
+		}
+	}
+	return err == nil && len(violations) == 0
+}
@@ -0,0 +1,171 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command csvcut selects/reorders columns and filters rows of CSV files.
+//
+// Usage:
+//
+//	csvcut [flags] [file ...]
+//
+// Files are read in sequence (standard input when none is specified),
+// gzip/bzip2 compressed files are transparently decompressed (based on their extension).
+//
+// Columns are specified by number (first is 1) or range ("1,3-5"),
+// or by name when the first record is a header ("id,name").
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gwenn/yacr"
+)
+
+var (
+	fields = flag.String("f", "", "selected columns (all by default)")
+	match  = flag.String("m", "", "only keep rows whose column matches a regexp (\"column=regexp\")")
+	invert = flag.Bool("v", false, "only keep rows not matched")
+	header = flag.Bool("H", false, "first record is a header (columns may be referenced by name)")
+	sep    = flag.String("d", ",", "input separator")
+	osep   = flag.String("od", "", "output separator (same as input by default)")
+	quoted = flag.Bool("q", true, "values may be quoted")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("csvcut: ")
+	flag.Parse()
+	in, err := separator(*sep)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := in
+	if *osep != "" {
+		if out, err = separator(*osep); err != nil {
+			log.Fatal(err)
+		}
+	}
+	w := yacr.NewWriter(os.Stdout, out, *quoted)
+	if flag.NArg() == 0 {
+		err = cut(w, os.Stdin, in, true)
+	}
+	for i, path := range flag.Args() {
+		var f io.ReadCloser
+		if f, err = yacr.Zopen(path); err != nil {
+			break
+		}
+		err = cut(w, f, in, i == 0)
+		_ = f.Close()
+		if err != nil {
+			err = fmt.Errorf("%s: %v", path, err)
+			break
+		}
+	}
+	w.Flush()
+	if err == nil {
+		err = w.Err()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func separator(s string) (byte, error) {
+	if s == `\t` {
+		return '\t', nil
+	}
+	if len(s) != 1 {
+		return 0, fmt.Errorf("invalid separator: %q", s)
+	}
+	return s[0], nil
+}
+
+// cut copies the selected columns and rows of rd to w.
+// The header (if any) is written only when first is true.
+func cut(w *yacr.Writer, rd io.Reader, sep byte, first bool) error {
+	r := yacr.NewReader(rd, sep, *quoted, false)
+	var names []string
+	if *header {
+		var err error
+		if names, err = r.ReadHeader(); err != nil && err != io.EOF {
+			return err
+		}
+	}
+	var transforms []yacr.Transform
+	if *match != "" {
+		i := strings.IndexByte(*match, '=')
+		if i < 0 {
+			return fmt.Errorf("invalid filter: %q", *match)
+		}
+		col, err := column((*match)[:i], r.Headers)
+		if err != nil {
+			return err
+		}
+		re, err := regexp.Compile((*match)[i+1:])
+		if err != nil {
+			return err
+		}
+		transforms = append(transforms, yacr.Filter(func(fields [][]byte) bool {
+			return (col < len(fields) && re.Match(fields[col])) != *invert
+		}))
+	}
+	var indexes []int
+	if *fields != "" {
+		for _, f := range strings.Split(*fields, ",") {
+			if i := strings.IndexByte(f, '-'); i > 0 {
+				from, err1 := strconv.Atoi(f[:i])
+				to, err2 := strconv.Atoi(f[i+1:])
+				if _, named := r.Headers[f]; !named && err1 == nil && err2 == nil { // not a hyphenated name
+					if from < 1 || to < from {
+						return fmt.Errorf("invalid range: %q", f)
+					}
+					for j := from; j <= to; j++ {
+						indexes = append(indexes, j-1)
+					}
+					continue
+				}
+			}
+			col, err := column(f, r.Headers)
+			if err != nil {
+				return err
+			}
+			indexes = append(indexes, col)
+		}
+		transforms = append(transforms, yacr.Projection(indexes...))
+	}
+	if names != nil && first {
+		if indexes != nil {
+			selected := make([]string, len(indexes))
+			for i, j := range indexes {
+				if j < len(names) {
+					selected[i] = names[j]
+				}
+			}
+			names = selected
+		}
+		for _, name := range names {
+			w.WriteString(name)
+		}
+		w.EndOfRecord()
+	}
+	_, err := yacr.Copy(w, r, transforms...)
+	return err
+}
+
+// column returns the index (first is 0) of the column specified by number (first is 1) or name.
+func column(s string, headers map[string]int) (int, error) {
+	if i, ok := headers[s]; ok {
+		return i - 1, nil
+	}
+	if i, err := strconv.Atoi(s); err == nil && i > 0 {
+		return i - 1, nil
+	}
+	return 0, fmt.Errorf("unknown column: %q", s)
+}
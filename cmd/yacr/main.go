@@ -0,0 +1,189 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command yacr converts CSV files between dialects
+// (separator, quoting, line endings, encoding and compression).
+//
+// Usage:
+//
+//	yacr [flags] [file ...]
+//
+// Files are read in sequence (standard input when none is specified),
+// gzip/bzip2 compressed files are transparently decompressed (based on their extension).
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime/pprof"
+
+	"github.com/gwenn/yacr"
+)
+
+// dialects are the short names accepted in addition to the ones registered in yacr (excel, unix, mysql, ...).
+var dialects = map[string]yacr.Dialect{
+	"csv": {Sep: ',', Quoted: true},
+	"tsv": {Sep: '\t'},
+	"ssv": {Sep: ';', Quoted: true},
+	"psv": {Sep: '|', Quoted: true},
+}
+
+var (
+	from       = flag.String("from", "csv", "input dialect: csv, tsv, ssv (semicolon), psv (pipe), excel, excel-tab, unix, postgres or mysql")
+	to         = flag.String("to", "csv", "output dialect: csv, tsv, ssv (semicolon), psv (pipe), excel, excel-tab, unix, postgres or mysql")
+	sep        = flag.String("sep", "", "input separator (overrides dialect's one)")
+	osep       = flag.String("osep", "", "output separator (overrides dialect's one)")
+	quote      = flag.String("quote", "", "input quote character, like ' or ` (overrides dialect's one and enables quoting)")
+	oquote     = flag.String("oquote", "", "output quote character, like ' or ` (overrides dialect's one and enables quoting)")
+	guess      = flag.Bool("guess", false, "guess input separator")
+	crlf       = flag.Bool("crlf", false, "use \\r\\n as output line terminator")
+	enc        = flag.String("enc", "utf-8", "input encoding: utf-8, latin1, utf-16le, utf-16be or auto (detected from the first 4 KB of each file)")
+	oenc       = flag.String("oenc", "utf-8", "output encoding: utf-8, latin1, utf-16le or utf-16be")
+	gz         = flag.Bool("gz", false, "gzip output")
+	output     = flag.String("o", "", "output file (default standard output)")
+	cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("yacr: ")
+	flag.Parse()
+	if *cpuprofile != "" {
+		f, err := os.Create(*cpuprofile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = pprof.StartCPUProfile(f); err != nil {
+			log.Fatal(err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+	if err := run(flag.Args()); err != nil {
+		pprof.StopCPUProfile()
+		log.Fatal(err)
+	}
+}
+
+func run(paths []string) (err error) {
+	in, err := lookupDialect(*from, *sep, *quote)
+	if err != nil {
+		return err
+	}
+	out, err := lookupDialect(*to, *osep, *oquote)
+	if err != nil {
+		return err
+	}
+	ic, auto := yacr.UTF8, *enc == "auto"
+	if !auto {
+		if ic, err = yacr.ParseCharset(*enc); err != nil {
+			return err
+		}
+	}
+	oc, err := yacr.ParseCharset(*oenc)
+	if err != nil {
+		return err
+	}
+
+	var ow io.Writer = os.Stdout
+	if *output != "" {
+		var f *os.File
+		if f, err = os.Create(*output); err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		ow = f
+	}
+	if *gz {
+		zw := gzip.NewWriter(ow)
+		defer func() {
+			if cerr := zw.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		ow = zw
+	}
+	enc := oc.NewEncoder(ow)
+	defer func() {
+		if cerr := enc.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	w := yacr.NewWriterDialect(enc, out)
+	w.UseCRLF = w.UseCRLF || *crlf
+
+	if len(paths) == 0 {
+		err = convert(w, os.Stdin, in, ic, auto)
+	}
+	for _, path := range paths {
+		if err = convertFile(w, path, in, ic, auto); err != nil {
+			break
+		}
+	}
+	w.Flush()
+	if err == nil {
+		err = w.Err()
+	}
+	return err
+}
+
+func lookupDialect(name, sep, quote string) (yacr.Dialect, error) {
+	d, ok := dialects[name]
+	if !ok {
+		d, ok = yacr.LookupDialect(name)
+	}
+	if !ok {
+		return d, fmt.Errorf("unknown dialect: %s", name)
+	}
+	if sep == `\t` {
+		sep = "\t"
+	}
+	if len(sep) > 1 {
+		return d, fmt.Errorf("invalid separator: %q", sep)
+	} else if len(sep) == 1 {
+		d.Sep = sep[0]
+	}
+	if len(quote) > 1 {
+		return d, fmt.Errorf("invalid quote: %q", quote)
+	} else if len(quote) == 1 {
+		d.Quote, d.Quoted = quote[0], true
+	}
+	return d, nil
+}
+
+func convertFile(w *yacr.Writer, path string, in yacr.Dialect, ic yacr.Charset, auto bool) error {
+	f, err := yacr.Zopen(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = convert(w, f, in, ic, auto); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+func convert(w *yacr.Writer, rd io.Reader, in yacr.Dialect, ic yacr.Charset, auto bool) error {
+	if ic != yacr.UTF8 {
+		rd = ic.NewDecoder(rd)
+	}
+	r := yacr.NewReaderDialect(rd, in)
+	if auto {
+		if err := r.GuessCharset(); err != nil {
+			return err
+		}
+	}
+	if *guess {
+		r.GuessNext()
+	}
+	_, err := yacr.Copy(w, r)
+	return err
+}
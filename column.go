@@ -0,0 +1,61 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+// +build go1.23
+
+package yacr
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Column returns an iterator over the values of the column named name (see Reader.Headers),
+// the header record being scanned first when Headers is nil.
+// Fields following the column are skipped without being unescaped (see SkipRestOfRecord)
+// and records missing the column yield an empty value. Empty lines are skipped.
+// A yielded value is only valid until the next iteration.
+// An error (unknown column or parsing error) is yielded once and stops the iteration.
+func Column(r *Reader, name string) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		if r.Headers == nil {
+			if err := r.ScanHeaders(); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+		index, ok := r.Headers[name]
+		if !ok {
+			yield(nil, fmt.Errorf("yacr: unknown column %q", name))
+			return
+		}
+		index-- // first is 0
+		col := 0
+		for r.Scan() {
+			if col == 0 && r.EndOfRecord() && len(r.Bytes()) == 0 { // skip empty line (or line comment)
+				continue
+			} else if col < index {
+				col++
+				if r.EndOfRecord() { // missing field
+					col = 0
+					if !yield(r.Bytes()[:0], nil) {
+						return
+					}
+				}
+				continue
+			}
+			col = 0
+			if !yield(r.Bytes(), nil) {
+				return
+			} else if err := r.SkipRestOfRecord(); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+		if err := r.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+// +build go1.23
+
+package yacr_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestColumn(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  string
+		err   bool
+	}{
+		{"id", "id,name,desc\n1,a,\"x,\"\"y\"\"\nz\"\n\n2,b\n3", "1|2|3", false},
+		{"name", "id,name,desc\n1,a,\"x,\"\"y\"\"\nz\"\n\n2,b\n3", "a|b|", false},
+		{"desc", "id,name,desc\n1,a,\"x,\"\"y\"\"\nz\"\n2,b\n", "x,\"y\"\nz|", false},
+		{"name", "id,name\n1,\"a\n2,b\n", "", true},
+		{"unknown", "id,name\n1,a\n", "", true},
+	}
+	for _, test := range tests {
+		r := DefaultReader(strings.NewReader(test.input))
+		var values []string
+		var err error
+		for value, e := range Column(r, test.name) {
+			if e != nil {
+				err = e
+				break
+			}
+			values = append(values, string(value))
+		}
+		if (err != nil) != test.err {
+			t.Errorf("%s: got error %v", test.name, err)
+		} else if got := strings.Join(values, "|"); got != test.want {
+			t.Errorf("%s: got %q; want %q", test.name, got, test.want)
+		}
+	}
+	r := DefaultReader(strings.NewReader("id\n1\n2\n3\n"))
+	for value := range Column(r, "id") {
+		if string(value) == "2" {
+			break
+		}
+	}
+	if values, err := r.Record(); err != nil || len(values) != 1 || values[0] != "3" {
+		t.Errorf("got %q (%v); want [3]", values, err)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import "io"
+
+// ColumnValues holds the values of one column of a batch stored contiguously (like an Arrow binary array):
+// value i is Data[Offsets[i]:Offsets[i+1]].
+type ColumnValues struct {
+	Data    []byte
+	Offsets []int // len(Offsets) is the number of values + 1
+}
+
+// Len returns the number of values.
+func (c *ColumnValues) Len() int {
+	return len(c.Offsets) - 1
+}
+
+// Value returns the value i (first is 0).
+func (c *ColumnValues) Value(i int) []byte {
+	return c.Data[c.Offsets[i]:c.Offsets[i+1]]
+}
+
+// Columns is a column-major batch of records (see ReadColumns).
+type Columns []ColumnValues
+
+// Len returns the number of records in the batch.
+func (c Columns) Len() int {
+	if len(c) == 0 {
+		return 0
+	}
+	return c[0].Len()
+}
+
+// ReadColumns returns at most batch records (less only at the end of the input or on error) in column-major order.
+// Missing fields are returned as empty values (all columns have the same length).
+// Empty lines are skipped.
+// The returned columns are reused by the next call to ReadColumns
+// (so reading batches of similar sizes does not allocate).
+// It returns (nil, io.EOF) when there is no more record.
+func (s *Reader) ReadColumns(batch int) (Columns, error) {
+	cols := s.columns[:0]
+	rows, col := 0, 0
+	for rows < batch && s.Scan() {
+		if col == 0 && s.EndOfRecord() && len(s.Bytes()) == 0 { // skip empty line (or line comment)
+			continue
+		}
+		if col == len(cols) {
+			cols = addColumn(cols, rows)
+		}
+		c := &cols[col]
+		c.Data = append(c.Data, s.Bytes()...)
+		c.Offsets = append(c.Offsets, len(c.Data))
+		col++
+		if s.EndOfRecord() {
+			for ; col < len(cols); col++ { // missing fields
+				cols[col].Offsets = append(cols[col].Offsets, len(cols[col].Data))
+			}
+			rows++
+			col = 0
+		}
+	}
+	s.columns = cols
+	if err := s.Err(); err != nil {
+		return cols, err
+	} else if rows == 0 && batch > 0 {
+		return nil, io.EOF
+	}
+	return cols, nil
+}
+
+// addColumn appends an empty column (with rows empty values) reusing cols storage.
+func addColumn(cols Columns, rows int) Columns {
+	if len(cols) < cap(cols) {
+		cols = cols[:len(cols)+1]
+	} else {
+		cols = append(cols, ColumnValues{})
+	}
+	c := &cols[len(cols)-1]
+	c.Data = c.Data[:0]
+	c.Offsets = c.Offsets[:0]
+	for i := 0; i <= rows; i++ {
+		c.Offsets = append(c.Offsets, 0)
+	}
+	return cols
+}
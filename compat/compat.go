@@ -0,0 +1,245 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package compat exposes the encoding/csv API implemented on top of yacr,
+// so that projects can switch by changing one import path.
+//
+// Known differences with encoding/csv:
+//   - Comma and Comment must be single-byte characters,
+//   - a quote in an unquoted field is kept as is (ErrBareQuote is never reported),
+//   - TrimLeadingSpace is applied to values (so a quoted value preceded by spaces is not unquoted),
+//   - a line containing only an empty quoted value is skipped like an empty line,
+//   - the column reported by FieldPos (and ParseError) is a byte index relative to the start of the record
+//     (unless the field starts on a following line).
+package compat
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gwenn/yacr"
+)
+
+// ParseError is returned for parsing errors (see encoding/csv).
+type ParseError = csv.ParseError
+
+// Errors returned in ParseError.Err (the ones of encoding/csv).
+var (
+	ErrBareQuote     = csv.ErrBareQuote
+	ErrQuote         = csv.ErrQuote
+	ErrFieldCount    = csv.ErrFieldCount
+	ErrTrailingComma = csv.ErrTrailingComma // Deprecated: No longer used.
+)
+
+var errInvalidDelim = errors.New("csv: invalid field or comment delimiter")
+
+func validDelim(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && r < utf8.RuneSelf
+}
+
+// A Reader reads records from a CSV-encoded file (see encoding/csv.Reader).
+type Reader struct {
+	Comma            rune // field delimiter (set to ',' by NewReader)
+	Comment          rune // when not 0, lines beginning with it are ignored
+	FieldsPerRecord  int  // expected number of fields per record (set from the first record when 0, not checked when negative)
+	LazyQuotes       bool // a quote may appear in a quoted field
+	TrimLeadingSpace bool // leading white space in a field is ignored
+	ReuseRecord      bool // the slice returned by Read may be reused by the next call
+	TrailingComma    bool // Deprecated: No longer used.
+
+	rd     io.Reader
+	r      *yacr.Reader
+	record []string
+	pos    []position // position of each field of the most recent record
+}
+
+type position struct {
+	line, col int
+}
+
+// NewReader returns a new Reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{Comma: ',', rd: r}
+}
+
+// Read reads one record (a slice of fields) from r.
+// It returns the record along with ErrFieldCount when the number of fields is not the expected one.
+// It returns nil, io.EOF at the end of the input.
+func (r *Reader) Read() (record []string, err error) {
+	if r.ReuseRecord {
+		record, err = r.readRecord(r.record)
+		r.record = record
+	} else {
+		record, err = r.readRecord(nil)
+	}
+	return record, err
+}
+
+// ReadAll reads all the remaining records from r.
+func (r *Reader) ReadAll() (records [][]string, err error) {
+	for {
+		record, err := r.readRecord(nil)
+		if err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// FieldPos returns the line and column corresponding to the start of the field with the given index
+// in the slice most recently returned by Read (numbering starts at 1).
+// It panics if field is out of range.
+func (r *Reader) FieldPos(field int) (line, column int) {
+	if field < 0 || field >= len(r.pos) {
+		panic("out of range index passed to FieldPos")
+	}
+	p := &r.pos[field]
+	return p.line, p.col
+}
+
+// InputOffset returns the input stream byte offset of the current reader position.
+func (r *Reader) InputOffset() int64 {
+	if r.r == nil {
+		return 0
+	}
+	return r.r.InputOffset()
+}
+
+func (r *Reader) init() error {
+	if r.r != nil {
+		return nil
+	}
+	if !validDelim(r.Comma) || r.Comment != 0 && !validDelim(r.Comment) || r.Comma == r.Comment {
+		return errInvalidDelim
+	}
+	r.r = yacr.NewReader(r.rd, byte(r.Comma), true, false)
+	r.r.Comment = byte(r.Comment)
+	r.r.Lazy = r.LazyQuotes
+	r.r.QuotedNewlines = yacr.NewlinesLF
+	return nil
+}
+
+func (r *Reader) readRecord(dst []string) ([]string, error) {
+	if err := r.init(); err != nil {
+		return nil, err
+	}
+	dst = dst[:0]
+	r.pos = r.pos[:0]
+	var start, startLine int // offset and line of the start of the record
+	for {
+		line, offset := r.r.LineNumber(), int(r.r.InputOffset())
+		if !r.r.Scan() {
+			break
+		}
+		if len(dst) == 0 {
+			if r.r.EndOfRecord() && len(r.r.Bytes()) == 0 { // skip empty line (or line comment)
+				continue
+			}
+			start, startLine = offset, line
+		}
+		p := position{line: line, col: 1}
+		if line == startLine {
+			p.col = offset - start + 1
+		}
+		r.pos = append(r.pos, p)
+		value := r.r.Text()
+		if r.TrimLeadingSpace {
+			value = strings.TrimLeftFunc(value, unicode.IsSpace)
+		}
+		dst = append(dst, value)
+		if r.r.EndOfRecord() {
+			break
+		}
+	}
+	if err := r.r.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, err
+		}
+		if len(dst) == 0 {
+			dst, startLine = nil, r.r.LineNumber()
+		}
+		return dst, &ParseError{StartLine: startLine, Line: r.r.LineNumber(), Column: 1, Err: ErrQuote}
+	} else if len(dst) == 0 {
+		return nil, io.EOF
+	}
+	if r.FieldsPerRecord > 0 {
+		if len(dst) != r.FieldsPerRecord {
+			return dst, &ParseError{StartLine: startLine, Line: startLine, Column: 1, Err: ErrFieldCount}
+		}
+	} else if r.FieldsPerRecord == 0 {
+		r.FieldsPerRecord = len(dst)
+	}
+	return dst, nil
+}
+
+// A Writer writes records using CSV encoding (see encoding/csv.Writer).
+// Writes are buffered, so Flush must eventually be called.
+type Writer struct {
+	Comma   rune // field delimiter (set to ',' by NewWriter)
+	UseCRLF bool // true to use \r\n as the line terminator
+
+	wr io.Writer
+	w  *yacr.Writer
+}
+
+// NewWriter returns a new Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{Comma: ',', wr: w}
+}
+
+// Write writes a single CSV record along with any necessary quoting.
+func (w *Writer) Write(record []string) error {
+	if w.w == nil {
+		if !validDelim(w.Comma) {
+			return errInvalidDelim
+		}
+		w.w = yacr.NewWriter(w.wr, byte(w.Comma), true)
+		w.w.QuoteSpaces = true
+	}
+	w.w.UseCRLF = w.UseCRLF
+	for _, field := range record {
+		if field == `\.` { // quoted like encoding/csv (Postgres end-of-data marker)
+			w.w.Quoting = yacr.QuoteAll
+			w.w.WriteString(field)
+			w.w.Quoting = yacr.QuoteMinimal
+		} else {
+			w.w.WriteString(field)
+		}
+	}
+	w.w.EndOfRecord()
+	return w.w.Err()
+}
+
+// WriteAll writes multiple CSV records using Write and then calls Flush, returning any error from the Flush.
+func (w *Writer) WriteAll(records [][]string) error {
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (w *Writer) Flush() {
+	if w.w != nil {
+		w.w.Flush()
+	}
+}
+
+// Error reports any error that has occurred during a previous Write or Flush.
+func (w *Writer) Error() error {
+	if w.w == nil {
+		return nil
+	}
+	return w.w.Err()
+}
@@ -0,0 +1,109 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compat_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gwenn/yacr/compat"
+)
+
+func TestReader(t *testing.T) {
+	var tests = []struct {
+		name              string
+		input             string
+		comma, comment    rune
+		fieldsPerRecord   int
+		lazy, trimLeading bool
+	}{
+		{name: "Simple", input: "a,b,c\n1,2,3\n"},
+		{name: "CRLF", input: "a,b\r\n\"c\r\nd\",e\r\n"},
+		{name: "Quoted", input: "\"a,\"\"b\"\"\",c\n\n\"\",x\n"},
+		{name: "Semicolon", input: "a;b\n1;2", comma: ';'},
+		{name: "Comment", input: "#x,y\na,b\n", comment: '#'},
+		{name: "FieldCount", input: "a,b\n1,2,3\n"},
+		{name: "NoFieldCount", input: "a,b\n1,2,3\n", fieldsPerRecord: -1},
+		{name: "Trim", input: "a,  b,\tc\n", trimLeading: true},
+		{name: "Lazy", input: "\"a\"b\",c\n", lazy: true},
+		{name: "BadQuote", input: "a,b\n\"c\"d,e\n"},
+		{name: "Unterminated", input: "a,b\n1,\"c\n"},
+		{name: "InvalidComma", input: "a\n", comma: '"'},
+	}
+	for _, test := range tests {
+		sr := csv.NewReader(strings.NewReader(test.input))
+		cr := compat.NewReader(strings.NewReader(test.input))
+		if test.comma != 0 {
+			sr.Comma, cr.Comma = test.comma, test.comma
+		}
+		sr.Comment, cr.Comment = test.comment, test.comment
+		sr.FieldsPerRecord, cr.FieldsPerRecord = test.fieldsPerRecord, test.fieldsPerRecord
+		sr.LazyQuotes, cr.LazyQuotes = test.lazy, test.lazy
+		sr.TrimLeadingSpace, cr.TrimLeadingSpace = test.trimLeading, test.trimLeading
+		for i := 0; ; i++ {
+			want, werr := sr.Read()
+			got, err := cr.Read()
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: record %d: got %q; want %q", test.name, i, got, want)
+			}
+			if (err == nil) != (werr == nil) {
+				t.Errorf("%s: record %d: got error %v; want %v", test.name, i, err, werr)
+			} else if werr != nil && !errors.Is(err, errors.Unwrap(werr)) && err.Error() != werr.Error() {
+				t.Errorf("%s: record %d: got error %v; want %v", test.name, i, err, werr)
+			}
+			if werr != nil && !errors.Is(werr, csv.ErrFieldCount) {
+				break
+			}
+		}
+	}
+}
+
+func TestFieldPos(t *testing.T) {
+	r := compat.NewReader(strings.NewReader("a,bc,\"d\"\n\n12,3,4\n"))
+	r.Read()
+	r.Read()
+	for i, want := range [][2]int{{3, 1}, {3, 4}, {3, 6}} {
+		if line, col := r.FieldPos(i); line != want[0] || col != want[1] {
+			t.Errorf("field %d: got (%d, %d); want (%d, %d)", i, line, col, want[0], want[1])
+		}
+	}
+	if r.InputOffset() != 17 {
+		t.Errorf("got offset %d; want 17", r.InputOffset())
+	}
+}
+
+func TestWriter(t *testing.T) {
+	records := [][]string{{"a", "b,c", "d\"e"}, {"", " f", "g\nh"}, {`\.`, `\.x`}}
+	for _, crlf := range []bool{false, true} {
+		var got, want bytes.Buffer
+		cw := compat.NewWriter(&got)
+		sw := csv.NewWriter(&want)
+		cw.Comma, sw.Comma = ';', ';'
+		cw.UseCRLF, sw.UseCRLF = crlf, crlf
+		if err := cw.WriteAll(records); err != nil {
+			t.Fatal(err)
+		}
+		sw.WriteAll(records)
+		if crlf { // encoding/csv also converts newlines in values
+			if g, w := strings.Replace(got.String(), "g\nh", "g\r\nh", 1), want.String(); g != w {
+				t.Errorf("got %q; want %q", g, w)
+			}
+		} else if got.String() != want.String() {
+			t.Errorf("got %q; want %q", got.String(), want.String())
+		}
+	}
+	if err := compat.NewWriter(&bytes.Buffer{}).Write([]string{"a"}); err != nil {
+		t.Error(err)
+	}
+	w := compat.NewWriter(&bytes.Buffer{})
+	w.Comma = '\n'
+	if err := w.Write([]string{"a"}); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.20
+// +build !go1.20
+
+package yacr
+
+import "unsafe"
+
+// stringBytes returns a byte slice sharing s storage (it must not be modified).
+func stringBytes(s string) []byte {
+	return *(*[]byte)(unsafe.Pointer(&struct {
+		string
+		int
+	}{s, len(s)}))
+}
+
+// bytesString returns a string sharing b storage (b must not be modified while the string is used).
+func bytesString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
@@ -0,0 +1,184 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Dialect groups the options describing a CSV flavour,
+// to configure a Reader (see NewReaderDialect) and a Writer (see NewWriterDialect) identically.
+type Dialect struct {
+	Sep        byte    // values separator
+	Quoted     bool    // specify if values may be quoted (rfc4180)
+	Quote      byte    // character enclosing quoted values. Double quote when 0.
+	Escape     byte    // escape character (see Reader.Escape and Writer.Escape). Disabled when 0.
+	CRLF       bool    // true to use \r\n as the line terminator (the Reader accepts both)
+	Quoting    Quoting // policy telling which values are quoted by the Writer
+	QuoteEmpty bool    // true when the Writer quotes empty values (to distinguish them from nulls)
+	Null       string  // token written for null values by the Writer
+	Comment    byte    // character marking the start of a line comment (ignored by the Writer). Disabled when 0.
+	Trim       bool    // specify if the Reader trims spaces of unquoted values
+	Lazy       bool    // specify if the Reader accepts quoted values containing unescaped quotes (see Reader.Lazy)
+	Header     bool    // true when the first record is a header row (informative, set by Sniff)
+}
+
+// Built-in dialects
+var (
+	Excel       = Dialect{Sep: ',', Quoted: true, CRLF: true}        // Excel CSV export
+	ExcelTab    = Dialect{Sep: '\t', Quoted: true, CRLF: true}       // Excel tab-delimited export
+	Unix        = Dialect{Sep: ',', Quoted: true, Quoting: QuoteAll} // all values quoted, \n terminated
+	PostgresCSV = Dialect{Sep: ',', Quoted: true, QuoteEmpty: true}  // COPY ... WITH (FORMAT csv): nulls are unquoted empty values
+	MySQL       = Dialect{Sep: '\t', Escape: '\\', Null: `\N`}       // SELECT ... INTO OUTFILE defaults
+)
+
+var dialects = struct {
+	sync.RWMutex
+	m map[string]Dialect
+}{m: map[string]Dialect{
+	"excel":     Excel,
+	"excel-tab": ExcelTab,
+	"unix":      Unix,
+	"postgres":  PostgresCSV,
+	"mysql":     MySQL,
+}}
+
+// RegisterDialect makes a dialect available by name (case insensitive) to LookupDialect.
+// A dialect previously registered with the same name is replaced.
+func RegisterDialect(name string, d Dialect) {
+	dialects.Lock()
+	dialects.m[strings.ToLower(name)] = d
+	dialects.Unlock()
+}
+
+// LookupDialect returns the dialect registered with name (case insensitive).
+// Built-in dialects are registered as "excel", "excel-tab", "unix", "postgres" and "mysql".
+func LookupDialect(name string) (Dialect, bool) {
+	dialects.RLock()
+	d, ok := dialects.m[strings.ToLower(name)]
+	dialects.RUnlock()
+	return d, ok
+}
+
+// NewReaderDialect returns a new CSV scanner to read from r using d.
+func NewReaderDialect(r io.Reader, d Dialect) *Reader {
+	s := NewReader(r, d.Sep, d.Quoted, false)
+	s.Quote = d.Quote
+	s.Escape = d.Escape
+	s.Comment = d.Comment
+	s.Trim = d.Trim
+	s.Lazy = d.Lazy
+	return s
+}
+
+// NewWriterDialect returns a new CSV writer using d.
+func NewWriterDialect(w io.Writer, d Dialect) *Writer {
+	wr := NewWriter(w, d.Sep, d.Quoted)
+	wr.Quote = d.Quote
+	wr.Escape = d.Escape
+	wr.UseCRLF = d.CRLF
+	wr.Quoting = d.Quoting
+	wr.QuoteEmpty = d.QuoteEmpty
+	wr.Null = d.Null
+	return wr
+}
+
+// jsonDialect is the JSON representation of a Dialect:
+// characters are encoded as one-byte strings (omitted when disabled).
+type jsonDialect struct {
+	Sep        string  `json:"sep"`
+	Quoted     bool    `json:"quoted"`
+	Quote      string  `json:"quote,omitempty"`
+	Escape     string  `json:"escape,omitempty"`
+	CRLF       bool    `json:"crlf,omitempty"`
+	Quoting    Quoting `json:"quoting,omitempty"`
+	QuoteEmpty bool    `json:"quoteEmpty,omitempty"`
+	Null       string  `json:"null,omitempty"`
+	Comment    string  `json:"comment,omitempty"`
+	Trim       bool    `json:"trim,omitempty"`
+	Lazy       bool    `json:"lazy,omitempty"`
+	Header     bool    `json:"header,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// Only ASCII characters are supported (a single byte >= 0x80 is not valid UTF-8).
+func (d Dialect) MarshalJSON() ([]byte, error) {
+	for _, c := range []byte{d.Sep, d.Quote, d.Escape, d.Comment} {
+		if c >= utf8.RuneSelf {
+			return nil, fmt.Errorf("yacr: invalid dialect character: %q (ASCII expected)", c)
+		}
+	}
+	return json.Marshal(jsonDialect{
+		Sep:        char(d.Sep),
+		Quoted:     d.Quoted,
+		Quote:      char(d.Quote),
+		Escape:     char(d.Escape),
+		CRLF:       d.CRLF,
+		Quoting:    d.Quoting,
+		QuoteEmpty: d.QuoteEmpty,
+		Null:       d.Null,
+		Comment:    char(d.Comment),
+		Trim:       d.Trim,
+		Lazy:       d.Lazy,
+		Header:     d.Header,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// A string is accepted as the name of a registered dialect (see LookupDialect).
+func (d *Dialect) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		var ok bool
+		if *d, ok = LookupDialect(name); !ok {
+			return fmt.Errorf("yacr: unknown dialect: %s", name)
+		}
+		return nil
+	}
+	var jd jsonDialect
+	if err := json.Unmarshal(data, &jd); err != nil {
+		return err
+	}
+	var v Dialect
+	var err error
+	if v.Sep, err = unchar("sep", jd.Sep); err != nil {
+		return err
+	} else if v.Sep == 0 {
+		return fmt.Errorf("yacr: missing dialect separator")
+	}
+	if v.Quote, err = unchar("quote", jd.Quote); err != nil {
+		return err
+	}
+	if v.Escape, err = unchar("escape", jd.Escape); err != nil {
+		return err
+	}
+	if v.Comment, err = unchar("comment", jd.Comment); err != nil {
+		return err
+	}
+	v.Quoted, v.CRLF, v.Quoting, v.QuoteEmpty, v.Null, v.Trim, v.Lazy, v.Header = jd.Quoted, jd.CRLF, jd.Quoting, jd.QuoteEmpty, jd.Null, jd.Trim, jd.Lazy, jd.Header
+	*d = v
+	return nil
+}
+
+func char(c byte) string {
+	if c == 0 {
+		return ""
+	}
+	return string([]byte{c})
+}
+
+func unchar(name, s string) (byte, error) {
+	if len(s) > 1 || len(s) == 1 && s[0] >= utf8.RuneSelf {
+		return 0, fmt.Errorf("yacr: invalid dialect %s: %q (one ASCII character expected)", name, s)
+	} else if len(s) == 0 {
+		return 0, nil
+	}
+	return s[0], nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestDialects(t *testing.T) {
+	var tests = []struct {
+		name string
+		want string
+	}{
+		{"excel", "a,\"b,c\",\r\n1,,\r\n"},
+		{"Excel-Tab", "a\tb,c\t\r\n1\t\t\r\n"},
+		{"unix", "\"a\",\"b,c\",\n\"1\",\"\",\n"},
+		{"postgres", "a,\"b,c\",\n1,\"\",\n"},
+		{"mysql", "a\tb,c\t\\N\n1\t\t\\N\n"},
+	}
+	for _, test := range tests {
+		d, ok := LookupDialect(test.name)
+		if !ok {
+			t.Errorf("%s: dialect not found", test.name)
+			continue
+		}
+		var b bytes.Buffer
+		w := NewWriterDialect(&b, d)
+		w.WriteString("a")
+		w.WriteString("b,c")
+		w.WriteNull()
+		w.EndOfRecord()
+		w.WriteValue(1)
+		w.WriteString("")
+		w.WriteNull()
+		w.EndOfRecord()
+		w.Flush()
+		if err := w.Err(); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if b.String() != test.want {
+			t.Errorf("%s: got %q; want %q", test.name, b.String(), test.want)
+		}
+		r := NewReaderDialect(&b, d)
+		values, err := r.Record()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if len(values) != 3 || values[1] != "b,c" {
+			t.Errorf("%s: got %q", test.name, values)
+		}
+	}
+	if _, ok := LookupDialect("unknown"); ok {
+		t.Error("unexpected dialect")
+	}
+	d := Dialect{Sep: ';', Quoted: true, Comment: '#'}
+	RegisterDialect("Semicolon", d)
+	if got, ok := LookupDialect("semicolon"); !ok || !reflect.DeepEqual(got, d) {
+		t.Errorf("got %v; want %v", got, d)
+	}
+	r := NewReaderDialect(bytes.NewBufferString(`"a "b" c",d`), Dialect{Sep: ',', Quoted: true, Lazy: true})
+	if values, err := r.Record(); err != nil || !reflect.DeepEqual(values, []string{`a "b" c`, "d"}) {
+		t.Errorf("got %q (%v)", values, err)
+	}
+}
+
+func TestDialectJSON(t *testing.T) {
+	for _, d := range []Dialect{Excel, ExcelTab, Unix, PostgresCSV, MySQL, {Sep: ';', Quoted: true, Quote: '\'', Comment: '#', Trim: true}, {Sep: ',', Quoted: true, Lazy: true, Header: true}} {
+		b, err := json.Marshal(d)
+		if err != nil {
+			t.Errorf("%v: %v", d, err)
+			continue
+		}
+		var got Dialect
+		if err = json.Unmarshal(b, &got); err != nil {
+			t.Errorf("%s: %v", b, err)
+		} else if !reflect.DeepEqual(got, d) {
+			t.Errorf("%s: got %v; want %v", b, got, d)
+		}
+	}
+	b, err := json.Marshal(MySQL)
+	if want := `{"sep":"\t","quoted":false,"escape":"\\","null":"\\N"}`; err != nil || string(b) != want {
+		t.Errorf("got %s (%v); want %s", b, err, want)
+	}
+	var d Dialect
+	if err = json.Unmarshal([]byte(`"unix"`), &d); err != nil || !reflect.DeepEqual(d, Unix) {
+		t.Errorf("got %v (%v); want %v", d, err, Unix)
+	}
+	if err = json.Unmarshal([]byte(`{"sep":",","quoting":"nonnumeric"}`), &d); err != nil || d.Quoting != QuoteNonNumeric {
+		t.Errorf("got %v (%v)", d, err)
+	}
+	for _, input := range []string{`"unknown"`, `{"sep":"::"}`, `{"quoted":true}`, `{"sep":",","quoting":"some"}`} {
+		if err = json.Unmarshal([]byte(input), &d); err == nil {
+			t.Errorf("%s: error expected", input)
+		}
+	}
+	if b, err = json.Marshal(Dialect{Sep: 0xA7}); err == nil {
+		t.Errorf("got %q; error expected", b)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+)
+
+// fakeDriver records statements and their arguments.
+// Query results are configured by query string.
+type fakeDriver struct {
+	mu  sync.Mutex
+	dbs map[string]*fakeDB
+}
+
+type fakeDB struct {
+	log     []string
+	args    [][]driver.Value
+	results map[string]*fakeRows
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+	i    int
+}
+
+var fake = &fakeDriver{dbs: make(map[string]*fakeDB)}
+
+func init() {
+	sql.Register("fake", fake)
+}
+
+// openFake returns a new database named dsn.
+func openFake(dsn string) (*sql.DB, *fakeDB) {
+	fdb := &fakeDB{results: make(map[string]*fakeRows)}
+	fake.mu.Lock()
+	fake.dbs[dsn] = fdb
+	fake.mu.Unlock()
+	db, err := sql.Open("fake", dsn)
+	if err != nil {
+		panic(err)
+	}
+	db.SetMaxOpenConns(1)
+	return db, fdb
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	db, ok := d.dbs[dsn]
+	if !ok {
+		return nil, errors.New("unknown database: " + dsn)
+	}
+	return &fakeConn{db}, nil
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c, query}, nil
+}
+func (c *fakeConn) Close() error {
+	return nil
+}
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.log = append(c.db.log, "BEGIN")
+	return c, nil
+}
+func (c *fakeConn) Commit() error {
+	c.db.log = append(c.db.log, "COMMIT")
+	return nil
+}
+func (c *fakeConn) Rollback() error {
+	c.db.log = append(c.db.log, "ROLLBACK")
+	return nil
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.conn.db
+	db.log = append(db.log, s.query)
+	db.args = append(db.args, args)
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if rows, ok := s.conn.db.results[s.query]; ok {
+		return &fakeRows{cols: rows.cols, rows: rows.rows}, nil
+	}
+	return &fakeRows{}, nil
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.cols
+}
+func (r *fakeRows) Close() error {
+	return nil
+}
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.i])
+	r.i++
+	return nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// FileWriter is a Writer to a file with a durability policy:
+// records are guaranteed to be persisted only after Sync, or Close when SyncOnClose is set.
+type FileWriter struct {
+	*Writer
+	name    string
+	f       *os.File
+	zw      *gzip.Writer // nil when the file is not compressed
+	n       int          // number of records written since the last sync
+	records int64        // number of records written
+	header  bool         // true when the first record is a header (see Dialect.Header)
+	digest  digestWriter // file content hash and size
+
+	SyncOnClose bool // fsync the file on Close
+	SyncEvery   int  // when positive, records are flushed and the file fsynced every SyncEvery records
+}
+
+// CreateFile creates (or truncates) the named file and returns a Writer using d.
+// Files with a .gz extension are gzip compressed (see Zopen).
+func CreateFile(name string, d Dialect) (*FileWriter, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	fw := &FileWriter{name: name, f: f, header: d.Header, digest: digestWriter{w: f, h: sha256.New()}}
+	var w io.Writer = &fw.digest
+	if path.Ext(name) == ".gz" {
+		fw.zw = gzip.NewWriter(w)
+		w = fw.zw
+	}
+	fw.Writer = NewWriterDialect(w, d)
+	fw.Writer.eor = fw.endOfRecord
+	return fw, nil
+}
+
+func (fw *FileWriter) endOfRecord() {
+	fw.records++
+	fw.n++
+	if fw.SyncEvery > 0 && fw.n >= fw.SyncEvery {
+		fw.setErr(fw.Sync())
+	}
+}
+
+// Sync flushes buffered records (and compressed data) and commits the file content to stable storage.
+func (fw *FileWriter) Sync() error {
+	fw.Flush()
+	if fw.zw != nil {
+		fw.setErr(fw.zw.Flush())
+	}
+	if fw.err == nil {
+		fw.setErr(fw.f.Sync())
+	}
+	fw.n = 0
+	return fw.err
+}
+
+// Close flushes buffered records, fsyncs the file when SyncOnClose is set and closes it.
+// It returns the first error encountered by the Writer.
+func (fw *FileWriter) Close() error {
+	fw.Flush()
+	if fw.zw != nil {
+		fw.setErr(fw.zw.Close())
+	}
+	if fw.SyncOnClose && fw.err == nil {
+		fw.setErr(fw.f.Sync())
+	}
+	fw.setErr(fw.f.Close())
+	return fw.err
+}
+
+// ManifestEntry returns the description of the file content written so far
+// (complete once the FileWriter is closed).
+// The file is identified by its base name (the manifest is delivered alongside)
+// and the header record (see Dialect.Header and SetHeader) is not counted.
+func (fw *FileWriter) ManifestEntry() ManifestEntry {
+	records := fw.records
+	if (fw.header || fw.Writer.header != nil) && records > 0 {
+		records--
+	}
+	return ManifestEntry{
+		File:    filepath.Base(fw.name),
+		Records: records,
+		Size:    fw.digest.n,
+		SHA256:  hex.EncodeToString(fw.digest.h.Sum(nil)),
+	}
+}
+
+// digestWriter hashes and counts the bytes written to w.
+type digestWriter struct {
+	w io.Writer
+	h hash.Hash
+	n int64
+}
+
+func (d *digestWriter) Write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	d.h.Write(p[:n])
+	d.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,106 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestCreateFile(t *testing.T) {
+	dir := writeFiles(t, nil)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"out.csv", "out.csv.gz"} {
+		path := filepath.Join(dir, name)
+		w, err := CreateFile(path, Excel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.SyncOnClose = true
+		w.SyncEvery = 2
+		w.WriteRecord("a", "b")
+		w.WriteRecord(1, 2)
+		if name == "out.csv" { // synced after two records
+			if b, err := ioutil.ReadFile(path); err != nil || string(b) != "a,b\r\n1,2\r\n" {
+				t.Errorf("%s: got %q (%v)", name, b, err)
+			}
+		}
+		w.WriteRecord(3, 4)
+		if err = w.Close(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		rc, err := Zopen(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if want := "a,b\r\n1,2\r\n3,4\r\n"; err != nil || string(b) != want {
+			t.Errorf("%s: got %q (%v); want %q", name, b, err, want)
+		}
+	}
+	if _, err := CreateFile(filepath.Join(dir, "missing", "out.csv"), Excel); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestManifest(t *testing.T) {
+	dir := writeFiles(t, nil)
+	defer os.RemoveAll(dir)
+	var m Manifest
+	for i, name := range []string{"part-1.csv", "part-2.csv.gz"} {
+		w, err := CreateFile(filepath.Join(dir, name), Unix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 {
+			w.SetHeader([]string{"i", "j"}) // not counted
+		}
+		for j := 0; j <= i; j++ {
+			w.WriteRecord(i, j)
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		e := w.ManifestEntry()
+		b, err := ioutil.ReadFile(filepath.Join(dir, e.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(b)
+		if e.File != name || e.Records != int64(i+1) || e.Size != int64(len(b)) || e.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("%s: unexpected entry: %+v", name, e)
+		}
+		m = append(m, e)
+	}
+	path := filepath.Join(dir, "manifest.json")
+	if err := m.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	var got Manifest
+	if b, err := ioutil.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if err = json.Unmarshal(b, &got); err != nil || !reflect.DeepEqual(got, m) {
+		t.Errorf("got %+v (%v); want %+v", got, err, m)
+	}
+	path = filepath.Join(dir, "manifest.csv")
+	if err := m.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if want := "file,records,size,sha256\n" + m[0].File + ",1,"; !strings.HasPrefix(string(b), want) {
+		t.Errorf("got %q; want prefix %q", b, want)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// NewFixedReader returns a new scanner reading fixed-width records (like mainframe exports) from r:
+// each line is split into fields of widths bytes (no separator, no quoting and no comment).
+// A line shorter than the sum of widths gives a record with fewer fields (the last one being partial)
+// and characters following the last field are ignored (filler).
+// Fields are trimmed (see Trim) as they are usually padded with spaces.
+// When widths is empty, each line is one field. FieldReader is not supported.
+// It panics if a width is not positive.
+func NewFixedReader(r io.Reader, widths []int) *Reader {
+	checkWidths(widths)
+	s := NewReader(r, 0, false, false)
+	if len(widths) == 0 {
+		widths = []int{maxTokenSize}
+	}
+	s.widths = append([]int(nil), widths...)
+	s.Trim = true
+	return s
+}
+
+func checkWidths(widths []int) {
+	for i, w := range widths {
+		if w < 1 {
+			panic(fmt.Sprintf("yacr: invalid width of column %d: %d", i+1, w))
+		}
+	}
+}
+
+// scanFixedField is the split path used by fixed-width Readers (see NewFixedReader).
+func (s *Reader) scanFixedField(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 && s.eor {
+		return 0, nil, nil
+	}
+	if s.eor {
+		s.fixed = 0
+	}
+	end := bytes.IndexByte(data, '\n')
+	line := data
+	if end >= 0 {
+		line = data[:end]
+	}
+	cr := len(line) > 0 && line[len(line)-1] == '\r' && (end >= 0 || !atEOF)
+	if cr {
+		line = line[:len(line)-1]
+	}
+	w := s.widths[s.fixed]
+	if s.fixed < len(s.widths)-1 && len(line) > w { // another field follows on the same line
+		s.eor = false
+		s.fixed++
+		return w, s.trimField(line[:w]), nil
+	} else if end < 0 && !atEOF {
+		return 0, nil, nil // request more data
+	}
+	// last field of the record
+	s.eor = true
+	field := line
+	if len(field) > w {
+		field = field[:w]
+	}
+	if end < 0 {
+		return len(data), s.trimField(field), nil
+	}
+	s.lineno++
+	if cr {
+		s.crlf++
+	} else {
+		s.lf++
+	}
+	return end + 1, s.trimField(field), nil
+}
+
+// ErrFixedWidth is the error returned when a value is wider than its column (see NewFixedWriter).
+var ErrFixedWidth = errors.New("yacr.Writer: value wider than its column")
+
+// NewFixedWriter returns a new writer of fixed-width records:
+// values are padded with spaces to the width (in bytes) of their column (see Align),
+// missing ones being replaced by spaces.
+// A value wider than its column (ErrFixedWidth), containing a newline (ErrNewLine) or exceeding the columns (ErrTooManyFields)
+// is reported as an error. Null values are written as the Null token.
+// It panics if a width is not positive.
+func NewFixedWriter(w io.Writer, widths []int) *Writer {
+	checkWidths(widths)
+	wr := NewWriter(w, 0, false)
+	wr.widths = make([]int, len(widths))
+	copy(wr.widths, widths)
+	return wr
+}
+
+// writeFixed pads value to the width of the current column.
+func (w *Writer) writeFixed(value []byte) bool {
+	if w.col >= len(w.widths) {
+		w.setErr(ErrTooManyFields)
+		return false
+	} else if bytes.IndexByte(value, '\n') >= 0 {
+		w.setErr(ErrNewLine)
+		return false
+	}
+	pad := w.widths[w.col] - len(value)
+	if pad < 0 {
+		w.setErr(ErrFixedWidth)
+		return false
+	}
+	var align Alignment
+	if w.col < len(w.Align) {
+		align = w.Align[w.col]
+	}
+	var left int
+	switch align {
+	case AlignRight:
+		left = pad
+	case AlignCenter:
+		left = pad / 2
+	}
+	w.rec = appendSpaces(w.rec, left)
+	w.rec = append(w.rec, value...)
+	w.rec = appendSpaces(w.rec, pad-left)
+	w.sor = false
+	w.col++
+	if len(w.rec) >= w.b.Size() { // huge record
+		w.writeRecord()
+	}
+	return w.err == nil
+}
+
+func appendSpaces(b []byte, n int) []byte {
+	for ; n > 0; n-- {
+		b = append(b, ' ')
+	}
+	return b
+}
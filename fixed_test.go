@@ -0,0 +1,105 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+var fixedTests = []struct {
+	Name   string
+	Input  string
+	Widths []int
+	Output [][]string
+}{
+	{Name: "Simple", Input: "ab cd  e\n", Widths: []int{2, 3, 3}, Output: [][]string{{"ab", "cd", "e"}}},
+	{Name: "NoEOL", Input: "ab cd  e", Widths: []int{2, 3, 3}, Output: [][]string{{"ab", "cd", "e"}}},
+	{Name: "CRLF", Input: "ab cd  e\r\nfg hi  j\r\n", Widths: []int{2, 3, 3}, Output: [][]string{{"ab", "cd", "e"}, {"fg", "hi", "j"}}},
+	{Name: "Short", Input: "ab c\nde\nfg\r\n", Widths: []int{2, 3, 3}, Output: [][]string{{"ab", "c"}, {"de"}, {"fg"}}},
+	{Name: "Filler", Input: "abcdXXX\n", Widths: []int{2, 2}, Output: [][]string{{"ab", "cd"}}},
+	{Name: "EmptyLines", Input: "\nab\n\ncd\n", Widths: []int{1, 1}, Output: [][]string{{"a", "b"}, {"c", "d"}}},
+	{Name: "Line", Input: " a b \n", Output: [][]string{{"a b"}}},
+}
+
+func TestFixedReader(t *testing.T) {
+	for _, tt := range fixedTests {
+		r := NewFixedReader(strings.NewReader(tt.Input), tt.Widths)
+		r.Buffer(make([]byte, 0, 2), 1<<20) // small buffer to check partial lines
+		var records [][]string
+		for {
+			record, err := r.Record()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.Name, err)
+				break
+			}
+			records = append(records, record)
+		}
+		if !reflect.DeepEqual(records, tt.Output) {
+			t.Errorf("%s: got %q; want %q", tt.Name, records, tt.Output)
+		}
+	}
+}
+
+func TestFixedWriter(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewFixedWriter(b, []int{3, 5, 4})
+	w.Align = []Alignment{AlignDefault, AlignRight, AlignCenter}
+	w.Null = "-"
+	w.WriteRecord("ab", 42, "c")
+	w.WriteRecord(nil, "")
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	} else if want := "ab    42 c  \n-" + strings.Repeat(" ", 11) + "\n"; b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+	r := NewFixedReader(b, []int{3, 5, 4})
+	for _, want := range [][]string{{"ab", "42", "c"}, {"-", "", ""}} {
+		if record, err := r.Record(); err != nil || !reflect.DeepEqual(record, want) {
+			t.Errorf("got %q (%v); want %q", record, err, want)
+		}
+	}
+
+	for _, tt := range []struct {
+		Values []interface{}
+		Err    error
+	}{
+		{[]interface{}{"abcd"}, ErrFixedWidth},
+		{[]interface{}{"a\n"}, ErrNewLine},
+		{[]interface{}{"a", "b", "c", "d"}, ErrTooManyFields},
+	} {
+		w = NewFixedWriter(b, []int{3, 5, 4})
+		if w.WriteRecord(tt.Values...) || w.Err() != tt.Err {
+			t.Errorf("%q: got %v; want %v", tt.Values, w.Err(), tt.Err)
+		}
+	}
+}
+
+func TestFixedInvalidWidth(t *testing.T) {
+	for _, widths := range [][]int{{2, -1, 3}, {0}} {
+		for _, fn := range []func(){
+			func() { NewFixedReader(strings.NewReader(""), widths) },
+			func() { NewFixedWriter(ioutil.Discard, widths) },
+		} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%v: panic expected", widths)
+					}
+				}()
+				fn()
+			}()
+		}
+	}
+}
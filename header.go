@@ -0,0 +1,235 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// SetHeader writes cols as the header record.
+// Subsequent WriteRecordByName, WriteMap and WriteStruct calls place values according to the header order:
+// missing values are written as nulls (see WriteNull)
+// and values of unknown columns are reported as an error (unless DropUnknown is set).
+func (w *Writer) SetHeader(cols []string) bool {
+	w.header = append(w.header[:0], cols...)
+	w.columns = make(map[string]int, len(cols))
+	for i, col := range cols {
+		w.columns[col] = i
+	}
+	for _, col := range cols {
+		if !w.WriteString(col) {
+			return false
+		}
+	}
+	w.EndOfRecord()
+	return w.err == nil
+}
+
+// WriteRecordByName writes one record from values by name (name1, value1, ...) placed according to the header (see SetHeader).
+func (w *Writer) WriteRecordByName(args ...interface{}) bool {
+	if len(args)%2 != 0 {
+		w.setErr(fmt.Errorf("expected an even number of arguments: %d", len(args)))
+		return false
+	} else if !w.startPlacing() {
+		return false
+	}
+	for i := 0; i < len(args); i += 2 {
+		name, ok := args[i].(string)
+		if !ok {
+			w.setErr(fmt.Errorf("non-string field name at %d: %T", i, args[i]))
+			return false
+		} else if !w.place(name, args[i+1]) {
+			return false
+		}
+	}
+	return w.writePlaced()
+}
+
+// WriteMap writes one record from values by name placed according to the header (see SetHeader).
+func (w *Writer) WriteMap(m map[string]interface{}) bool {
+	if !w.startPlacing() {
+		return false
+	}
+	for name, value := range m {
+		if !w.place(name, value) {
+			return false
+		}
+	}
+	return w.writePlaced()
+}
+
+// WriteHeaderFromStruct writes the field names of v (a struct or a pointer to a struct, see WriteStruct)
+// as the header (see SetHeader).
+func (w *Writer) WriteHeaderFromStruct(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		w.setErr(fmt.Errorf("unsupported type: %T", v))
+		return false
+	}
+	fields := fieldsOf(t)
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return w.SetHeader(names)
+}
+
+// WriteStruct writes one record from the exported fields of v (a struct or a pointer to a struct)
+// placed according to the header (see SetHeader and WriteHeaderFromStruct) or in field order when there is no header.
+// Fields are named by their "csv" tag (`csv:"-"` to ignore one) or by their Go name.
+func (w *Writer) WriteStruct(v interface{}) bool {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		w.setErr(fmt.Errorf("unsupported type: %T", v))
+		return false
+	} else if w.columns == nil {
+		for _, f := range fieldsOf(rv.Type()) {
+			if !w.WriteValue(rv.Field(f.index).Interface()) {
+				return false
+			}
+		}
+		w.EndOfRecord()
+		return w.err == nil
+	} else if !w.startPlacing() {
+		return false
+	}
+	for _, f := range fieldsOf(rv.Type()) {
+		if !w.place(f.name, rv.Field(f.index).Interface()) {
+			return false
+		}
+	}
+	return w.writePlaced()
+}
+
+// WriteStructs writes the header (the field names of the element type, see WriteStruct)
+// and one record per element of slice (a slice of structs or of pointers to structs, nil pointers being skipped).
+func (w *Writer) WriteStructs(slice interface{}) bool {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		w.setErr(fmt.Errorf("unsupported type: %T", slice))
+		return false
+	}
+	t := rv.Type().Elem()
+	ptr := t.Kind() == reflect.Ptr
+	if ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		w.setErr(fmt.Errorf("unsupported type: %T", slice))
+		return false
+	} else if !w.WriteHeaderFromStruct(reflect.Zero(t).Interface()) {
+		return false
+	}
+	fields := fieldsOf(t)
+	for i := 0; i < rv.Len(); i++ {
+		ev := rv.Index(i)
+		if ptr {
+			if ev.IsNil() {
+				continue
+			}
+			ev = ev.Elem()
+		}
+		for _, f := range fields {
+			if !w.WriteValue(ev.Field(f.index).Interface()) {
+				return false
+			}
+		}
+		w.EndOfRecord()
+	}
+	return w.err == nil
+}
+
+// startPlacing resets the values placed according to the header.
+func (w *Writer) startPlacing() bool {
+	if w.err != nil {
+		return false
+	} else if w.columns == nil {
+		w.setErr(errors.New("yacr.Writer: no header (see SetHeader)"))
+		return false
+	}
+	w.values = append(w.values[:0], make([]interface{}, len(w.header))...)
+	return true
+}
+
+// place stores value at the index of the column name.
+func (w *Writer) place(name string, value interface{}) bool {
+	i, ok := w.columns[name]
+	if !ok {
+		if w.DropUnknown {
+			return true
+		}
+		w.setErr(fmt.Errorf("yacr.Writer: unknown column: %q", name))
+		return false
+	}
+	w.values[i] = value
+	return true
+}
+
+// writePlaced writes the values placed according to the header.
+func (w *Writer) writePlaced() bool {
+	for i, v := range w.values {
+		if !w.WriteValue(v) {
+			return false
+		}
+		w.values[i] = nil // not retained
+	}
+	w.EndOfRecord()
+	return w.err == nil
+}
+
+type structField struct {
+	index  int
+	name   string
+	layout string // time layout specified by the "layout=" tag option (see TypedWriter)
+}
+
+// structFields caches exported fields by struct type.
+var structFields sync.Map
+
+func fieldsOf(t reflect.Type) []structField {
+	if fields, ok := structFields.Load(t); ok {
+		return fields.([]structField)
+	}
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+		name, layout := f.Name, ""
+		if tag := f.Tag.Get("csv"); tag == "-" {
+			continue
+		} else if tag != "" {
+			var opts string
+			if j := strings.IndexByte(tag, ','); j >= 0 {
+				tag, opts = tag[:j], tag[j+1:]
+			}
+			if tag != "" {
+				name = tag
+			}
+			for opts != "" {
+				if strings.HasPrefix(opts, "layout=") { // last option as a layout may contain commas
+					layout = opts[len("layout="):]
+					break
+				}
+				j := strings.IndexByte(opts, ',')
+				if j < 0 {
+					break
+				}
+				opts = opts[j+1:]
+			}
+		}
+		fields = append(fields, structField{index: i, name: name, layout: layout})
+	}
+	structFields.Store(t, fields)
+	return fields
+}
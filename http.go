@@ -0,0 +1,73 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"syscall"
+)
+
+// ServeCSV streams the records written by fn to an HTTP client.
+// Content-Type (text/csv) and Content-Disposition (attachment) headers are set unless already present,
+// once the first records are sent (or fn succeeds without writing anything).
+// The response is flushed to the client each time the Writer's buffer is full (when w implements http.Flusher).
+// When fn fails before anything has been sent, an Internal Server Error is replied (without Content-Disposition).
+// Errors caused by the client disconnecting are ignored.
+func ServeCSV(w http.ResponseWriter, fn func(*Writer) error) error {
+	fw := &flushWriter{w: w}
+	fw.f, _ = w.(http.Flusher)
+	cw := DefaultWriter(fw)
+	err := fn(cw)
+	if err == nil {
+		cw.Flush()
+		err = cw.Err()
+	}
+	if err == nil && fw.n == 0 {
+		setCSVHeaders(w.Header())
+	}
+	if err == nil || isDisconnect(err) {
+		return nil
+	}
+	if fw.n == 0 {
+		w.Header().Del("Content-Disposition")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+	return err
+}
+
+func setCSVHeaders(h http.Header) {
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", "text/csv; charset=utf-8")
+	}
+	if h.Get("Content-Disposition") == "" {
+		h.Set("Content-Disposition", "attachment")
+	}
+}
+
+// flushWriter flushes each chunk written to the HTTP client.
+type flushWriter struct {
+	w http.ResponseWriter
+	f http.Flusher
+	n int64 // number of bytes written
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	if fw.n == 0 { // headers are sent with the first chunk
+		setCSVHeaders(fw.w.Header())
+	}
+	n, err := fw.w.Write(p)
+	fw.n += int64(n)
+	if err == nil && fw.f != nil {
+		fw.f.Flush()
+	}
+	return n, err
+}
+
+func isDisconnect(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, http.ErrHandlerTimeout)
+}
@@ -0,0 +1,63 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestServeCSV(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Disposition", `attachment; filename="data.csv"`)
+	err := ServeCSV(rec, func(w *Writer) error {
+		w.WriteRecord("a", "b,c")
+		w.WriteRecord(1, 2)
+		return w.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("unexpected content type: %q", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="data.csv"` {
+		t.Errorf("unexpected content disposition: %q", cd)
+	}
+	if !rec.Flushed {
+		t.Error("response not flushed")
+	}
+	if body := rec.Body.String(); body != "a,\"b,c\"\n1,2\n" {
+		t.Errorf("unexpected body: %q", body)
+	}
+}
+
+func TestServeCSVError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := ServeCSV(rec, func(w *Writer) error {
+		return errors.New("test")
+	})
+	if err == nil {
+		t.Error("error expected")
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("got %d; want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != "" {
+		t.Errorf("unexpected content disposition: %q", cd)
+	}
+
+	err = ServeCSV(httptest.NewRecorder(), func(w *Writer) error {
+		return syscall.EPIPE
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// TypeInferrer infers the type and the nullability of each column from a sample of records,
+// to build the Schema used by AsRows and ScanAnyMap, a CREATE TABLE statement (see Schema.CreateTable)
+// or a struct definition.
+// A column is TypeInt, TypeFloat (integers and reals mixed), TypeBool (strconv.ParseBool syntax) or TypeTime
+// (see ColumnDef.Convert) when all its non null values have this type, TypeText otherwise.
+// A column is nullable when an empty field, a null token or a missing field (ragged record) is seen.
+type TypeInferrer struct {
+	Records int      // maximum number of records read by Infer (all when not positive)
+	Nulls   []string // tokens (like `\N` or "NULL") considered as nulls in addition to empty fields
+
+	types    []ColumnType
+	valued   []bool // true when the column has at least one non null value
+	nullable []bool
+	records  int // number of records added
+}
+
+// Add updates the inferred types with one record.
+func (ti *TypeInferrer) Add(record []string) {
+	for len(ti.types) < len(record) { // missing in previous records
+		ti.types = append(ti.types, TypeText)
+		ti.valued = append(ti.valued, false)
+		ti.nullable = append(ti.nullable, ti.records > 0)
+	}
+	ti.records++
+	for i := len(record); i < len(ti.types); i++ {
+		ti.nullable[i] = true
+	}
+	for i, field := range record {
+		if ti.isNull(field) {
+			ti.nullable[i] = true
+			continue
+		}
+		if !ti.valued[i] {
+			ti.valued[i] = true
+			ti.types[i] = inferType(field)
+		} else if ti.types[i] != TypeText {
+			ti.types[i] = mergeTypes(ti.types[i], inferType(field))
+		}
+	}
+}
+
+// Infer adds (see Add) the next records (at most Records) read from r and returns the inferred Schema (see Schema).
+// Columns are named by the header read by r.ReadHeader (if any).
+func (ti *TypeInferrer) Infer(r *Reader) (Schema, error) {
+	for n := 0; ti.Records <= 0 || n < ti.Records; n++ {
+		record, err := r.Record()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		ti.Add(record)
+	}
+	return ti.Schema(r.Header()), nil
+}
+
+// Schema returns the columns inferred from the records added so far,
+// named by names (by their number, first is 1, when a name is missing or empty).
+// A column without value is a nullable TypeText.
+func (ti *TypeInferrer) Schema(names []string) Schema {
+	n := len(ti.types)
+	if len(names) > n {
+		n = len(names)
+	}
+	schema := make(Schema, n)
+	for i := range schema {
+		c := ColumnDef{Name: strconv.Itoa(i + 1), Type: TypeText, Nullable: true}
+		if i < len(names) && names[i] != "" {
+			c.Name = names[i]
+		}
+		if i < len(ti.types) && ti.valued[i] {
+			c.Type, c.Nullable = ti.types[i], ti.nullable[i]
+		}
+		schema[i] = c
+	}
+	return schema
+}
+
+func (ti *TypeInferrer) isNull(field string) bool {
+	if field == "" {
+		return true
+	}
+	for _, null := range ti.Nulls {
+		if field == null {
+			return true
+		}
+	}
+	return false
+}
+
+// inferType returns the most specific type of text (see ColumnDef.Convert).
+func inferType(text string) ColumnType {
+	if _, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return TypeInt
+	} else if f, err := strconv.ParseFloat(text, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return TypeFloat
+	} else if _, err := strconv.ParseBool(text); err == nil {
+		return TypeBool
+	}
+	for _, layout := range timeLayouts {
+		if _, err := time.Parse(layout, text); err == nil {
+			return TypeTime
+		}
+	}
+	return TypeText
+}
+
+// mergeTypes returns the type of a column having values of types t and u.
+func mergeTypes(t, u ColumnType) ColumnType {
+	if t == u {
+		return t
+	} else if (t == TypeInt || t == TypeFloat) && (u == TypeInt || u == TypeFloat) {
+		return TypeFloat
+	}
+	return TypeText
+}
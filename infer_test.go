@@ -0,0 +1,54 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestTypeInferrer(t *testing.T) {
+	const input = "id,price,ok,day,name,note,empty\n" +
+		"1,3,true,2024-02-29,a,x,\n" +
+		"2,4.5,F,2024-03-01 10:00:00,,y,\n" +
+		"3,NULL,true,2024-03-02,c,1\n" +
+		"4,-1e3,false,2024-03-03,d,z,,extra\n" +
+		"x,x,x,x,x,x,x\n" // not sampled
+	r := DefaultReader(strings.NewReader(input))
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatal(err)
+	}
+	ti := &TypeInferrer{Records: 4, Nulls: []string{"NULL"}}
+	schema, err := ti.Infer(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Schema{
+		{Name: "id", Type: TypeInt},
+		{Name: "price", Type: TypeFloat, Nullable: true},
+		{Name: "ok", Type: TypeBool},
+		{Name: "day", Type: TypeTime},
+		{Name: "name", Type: TypeText, Nullable: true},
+		{Name: "note", Type: TypeText},
+		{Name: "empty", Type: TypeText, Nullable: true},
+		{Name: "8", Type: TypeText, Nullable: true},
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("got %+v; want %+v", schema, want)
+	}
+	if values, err := r.Record(); err != nil || values[0] != "x" {
+		t.Errorf("got %q (%v); want the fifth record", values, err)
+	}
+
+	ti = &TypeInferrer{}
+	ti.Add([]string{"1"})
+	ti.Add([]string{"2", "3"})
+	if got := ti.Schema(nil); !reflect.DeepEqual(got, Schema{{Name: "1", Type: TypeInt}, {Name: "2", Type: TypeInt, Nullable: true}}) {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+// Interner deduplicates strings so that repeated values share memory
+// (useful for low-cardinality columns like country codes or enums).
+// It is not safe for concurrent use.
+type Interner struct {
+	max     int // maximum number of interned strings (unlimited when <= 0)
+	strings map[string]string
+}
+
+// NewInterner returns a new Interner keeping at most max strings (unlimited when max <= 0).
+// Once full, values not already interned are returned as new strings.
+func NewInterner(max int) *Interner {
+	return &Interner{max: max, strings: make(map[string]string)}
+}
+
+// Intern returns the string equal to b (without allocation when it is already interned).
+func (in *Interner) Intern(b []byte) string {
+	if s, ok := in.strings[string(b)]; ok { // no allocation
+		return s
+	}
+	s := string(b)
+	if in.max <= 0 || len(in.strings) < in.max {
+		in.strings[s] = s
+	}
+	return s
+}
+
+// Len returns the number of interned strings.
+func (in *Interner) Len() int {
+	return len(in.strings)
+}
@@ -0,0 +1,151 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Violation describes a structural or schema problem found by a Linter.
+type Violation struct {
+	Line   int // line number where the record (or field) starts
+	Record int // record number (first is 1)
+	Column int // field number (first is 1), 0 when the whole record is concerned
+	Msg    string
+}
+
+func (v Violation) String() string {
+	if v.Column == 0 {
+		return fmt.Sprintf("line %d: record %d: %s", v.Line, v.Record, v.Msg)
+	}
+	return fmt.Sprintf("line %d, column %d: record %d: %s", v.Line, v.Column, v.Record, v.Msg)
+}
+
+// Linter checks records structure (consistent number of fields, header)
+// and, optionally, conformance to a schema (number of fields, types, nullability).
+type Linter struct {
+	Header bool   // first record is a header (its names must match Schema's ones when specified)
+	Schema Schema // optional
+}
+
+// Lint reads all records from r and returns the violations found.
+// A parsing error (like an unterminated quoted field) stops the check,
+// it is reported both as the last violation and as the returned error.
+func (l *Linter) Lint(r *Reader) ([]Violation, error) {
+	var violations []Violation
+	report := func(line, record, column int, format string, args ...interface{}) {
+		violations = append(violations, Violation{line, record, column, fmt.Sprintf(format, args...)})
+	}
+	width := len(l.Schema) // expected number of fields
+	var record, i, recordLine int
+	var names map[string]bool
+	for line := r.LineNumber(); r.Scan(); line = r.LineNumber() {
+		if i == 0 {
+			if r.EndOfRecord() && len(r.Bytes()) == 0 { // skip empty line (or line comment)
+				continue
+			}
+			record++
+			recordLine = line
+		}
+		header := l.Header && record == 1
+		if header {
+			name := r.Text()
+			if name == "" {
+				report(line, record, i+1, "empty header name")
+			} else if names[name] {
+				report(line, record, i+1, "duplicate header name %q", name)
+			}
+			if names == nil {
+				names = make(map[string]bool)
+			}
+			names[name] = true
+			if i < len(l.Schema) && l.Schema[i].Name != "" && l.Schema[i].Name != name {
+				report(line, record, i+1, "header name %q does not match schema name %q", name, l.Schema[i].Name)
+			}
+		}
+		if hasLoneCR(r.Bytes()) {
+			report(line, record, i+1, "stray carriage return")
+		}
+		if !header && i < len(l.Schema) {
+			c := l.Schema[i]
+			if len(r.Bytes()) == 0 {
+				if !c.Nullable {
+					report(line, record, i+1, "missing value for column %q", c.Name)
+				}
+			} else if _, err := c.Convert(r.Bytes()); err != nil {
+				report(line, record, i+1, "invalid %s value for column %q: %q", c.Type, c.Name, r.Text())
+			}
+		}
+		i++
+		if r.EndOfRecord() {
+			if width == 0 {
+				width = i
+			} else if i != width {
+				report(recordLine, record, 0, "got %d field(s), want %d", i, width)
+			}
+			i = 0
+		}
+	}
+	if err := r.Err(); err != nil {
+		report(r.LineNumber(), record, i+1, "%v", err)
+		return violations, err
+	}
+	if le := r.LineEndings(); le.Mixed() {
+		report(r.LineNumber(), record, 0, "mixed line endings: %d CRLF and %d LF", le.CRLF, le.LF)
+	}
+	return violations, nil
+}
+
+// hasLoneCR tells if value contains a carriage return not followed by a line feed
+// (CRLF being valid inside quoted values).
+func hasLoneCR(value []byte) bool {
+	for {
+		i := bytes.IndexByte(value, '\r')
+		if i < 0 {
+			return false
+		} else if i+1 == len(value) || value[i+1] != '\n' {
+			return true
+		}
+		value = value[i+2:]
+	}
+}
+
+// ParseSchema parses a schema specified as a comma separated list of 'name:type' columns,
+// where type is one of text, int, float, bool or time (text by default)
+// optionally followed by a '?' when the column is nullable.
+// For example: "id:int,name,price:float?".
+func ParseSchema(spec string) (Schema, error) {
+	var schema Schema
+	r := NewReader(strings.NewReader(spec), ',', true, false)
+	for r.Scan() {
+		c := ColumnDef{Name: r.Text()}
+		if i := strings.LastIndexByte(c.Name, ':'); i >= 0 {
+			t := c.Name[i+1:]
+			c.Name = c.Name[:i]
+			if n := len(t); n > 0 && t[n-1] == '?' {
+				c.Nullable = true
+				t = t[:n-1]
+			}
+			found := false
+			for j, name := range columnTypeNames {
+				if name == t {
+					c.Type = ColumnType(j)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("yacr: unknown type %q for column %q", t, c.Name)
+			}
+		}
+		schema = append(schema, c)
+		if r.EndOfRecord() {
+			break
+		}
+	}
+	return schema, r.Err()
+}
@@ -0,0 +1,84 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestLint(t *testing.T) {
+	schema, err := ParseSchema("id:int,name,price:float?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Schema{{Name: "id", Type: TypeInt}, {Name: "name"}, {Name: "price", Type: TypeFloat, Nullable: true}}
+	if !reflect.DeepEqual(schema, want) {
+		t.Fatalf("got %v; want %v", schema, want)
+	}
+	input := "id,label,price\n1,a,\n\nx,\"b\nb\",1.5\n3,,1,5\n4\n5,c,2\n\"6"
+	l := &Linter{Header: true, Schema: schema}
+	violations, err := l.Lint(DefaultReader(strings.NewReader(input)))
+	if err == nil {
+		t.Error("error expected")
+	}
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.String())
+	}
+	expected := []string{
+		`line 1, column 2: record 1: header name "label" does not match schema name "name"`,
+		`line 4, column 1: record 3: invalid int value for column "id": "x"`,
+		`line 6, column 2: record 4: missing value for column "name"`,
+		`line 6: record 4: got 4 field(s), want 3`,
+		`line 7: record 5: got 1 field(s), want 3`,
+		`line 9, column 1: record 6: non-terminated quoted field between lines 9 and 9`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q; want %q", got, expected)
+	}
+}
+
+func TestLintLineEndings(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,b\r\nc,\"d\"\ne\rf,g\r\n"))
+	violations, err := (&Linter{}).Lint(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.String())
+	}
+	expected := []string{
+		`line 3, column 1: record 3: stray carriage return`,
+		`line 4: record 3: mixed line endings: 2 CRLF and 1 LF`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q; want %q", got, expected)
+	}
+	if le := r.LineEndings(); le != (LineEndings{LF: 1, CRLF: 2}) {
+		t.Errorf("got %+v", le)
+	}
+}
+
+func TestLintQuotedCRLF(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,b\r\n\"c\r\nd\",e\r\n\"f\rg\",h\r\n"))
+	violations, err := (&Linter{}).Lint(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Violation{{Line: 4, Record: 3, Column: 1, Msg: "stray carriage return"}}; !reflect.DeepEqual(violations, want) {
+		t.Errorf("got %v; want %v", violations, want)
+	}
+}
+
+func TestParseSchemaError(t *testing.T) {
+	if _, err := ParseSchema("id:integer"); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+)
+
+// ManifestEntry describes one output file (see FileWriter.ManifestEntry).
+type ManifestEntry struct {
+	File    string `json:"file"`    // base name
+	Records int64  `json:"records"` // number of records (header excluded)
+	Size    int64  `json:"size"`    // size in bytes (after compression)
+	SHA256  string `json:"sha256"`  // hex encoded digest of the file content
+}
+
+// Manifest describes a set of output files, to be delivered alongside them.
+type Manifest []ManifestEntry
+
+// WriteCSV writes the manifest as CSV (with a file,records,size,sha256 header).
+func (m Manifest) WriteCSV(w io.Writer) error {
+	wr := DefaultWriter(w)
+	wr.WriteRecord("file", "records", "size", "sha256")
+	for _, e := range m {
+		wr.WriteRecord(e.File, e.Records, e.Size, e.SHA256)
+	}
+	wr.Flush()
+	return wr.Err()
+}
+
+// WriteJSON writes the manifest as a JSON array.
+func (m Manifest) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// WriteFile writes the manifest to the named file, as JSON when its extension is .json (as CSV otherwise).
+func (m Manifest) WriteFile(name string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	if path.Ext(name) == ".json" {
+		return m.WriteJSON(f)
+	}
+	return m.WriteCSV(f)
+}
@@ -0,0 +1,140 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"bufio"
+	"io"
+)
+
+// Alignment tells how a column's content is aligned.
+type Alignment int
+
+// Column alignments
+const (
+	AlignDefault Alignment = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+// MarkdownWriter provides an interface for writing records as a GitHub-flavored Markdown table.
+// The first record is used as the table header.
+// Successive calls to the Write method will automatically insert the cell delimiters.
+// The EndOfRecord method tells when a line break is inserted.
+type MarkdownWriter struct {
+	b      *bufio.Writer
+	col    int   // index of the next cell in the current record
+	header bool  // true when the header and delimiter rows have been written
+	err    error // sticky error.
+
+	Align []Alignment // alignment hints by column (missing ones are AlignDefault)
+}
+
+// NewMarkdownWriter returns a new Markdown table writer.
+func NewMarkdownWriter(w io.Writer) *MarkdownWriter {
+	return &MarkdownWriter{b: bufio.NewWriter(w)}
+}
+
+// Write ensures that value's pipes are escaped and newlines replaced by <br>.
+func (w *MarkdownWriter) Write(value []byte) bool {
+	if w.err != nil {
+		return false
+	}
+	if w.col == 0 {
+		w.writeString("| ")
+	} else {
+		w.writeString(" | ")
+	}
+	last := 0
+	for i, c := range value {
+		switch c {
+		case '|', '\\', '\r', '\n':
+		default:
+			continue
+		}
+		w.write(value[last:i])
+		last = i + 1
+		switch c {
+		case '\r':
+			if i+1 < len(value) && value[i+1] == '\n' {
+				continue
+			}
+			w.writeString("<br>")
+		case '\n':
+			w.writeString("<br>")
+		default:
+			w.setErr(w.b.WriteByte('\\'))
+			w.setErr(w.b.WriteByte(c))
+		}
+	}
+	w.write(value[last:])
+	w.col++
+	return w.err == nil
+}
+
+// WriteString ensures that value's pipes are escaped and newlines replaced by <br>.
+func (w *MarkdownWriter) WriteString(value string) bool {
+	return w.Write([]byte(value))
+}
+
+// EndOfRecord tells when a line break must be inserted.
+// After the first record (the header), the delimiter row is inserted.
+func (w *MarkdownWriter) EndOfRecord() {
+	if w.col == 0 { // empty record
+		w.Write([]byte{})
+	}
+	w.writeString(" |\n")
+	if !w.header {
+		for i := 0; i < w.col; i++ {
+			var align Alignment
+			if i < len(w.Align) {
+				align = w.Align[i]
+			}
+			switch align {
+			case AlignLeft:
+				w.writeString("| :-- ")
+			case AlignCenter:
+				w.writeString("| :-: ")
+			case AlignRight:
+				w.writeString("| --: ")
+			default:
+				w.writeString("| --- ")
+			}
+		}
+		w.writeString("|\n")
+		w.header = true
+	}
+	w.col = 0
+}
+
+// Flush ensures the writer's buffer is flushed.
+func (w *MarkdownWriter) Flush() {
+	w.setErr(w.b.Flush())
+}
+
+// Err returns the first error that was encountered by the MarkdownWriter.
+func (w *MarkdownWriter) Err() error {
+	return w.err
+}
+
+func (w *MarkdownWriter) write(b []byte) {
+	if _, err := w.b.Write(b); err != nil {
+		w.setErr(err)
+	}
+}
+
+func (w *MarkdownWriter) writeString(s string) {
+	if _, err := w.b.WriteString(s); err != nil {
+		w.setErr(err)
+	}
+}
+
+// setErr records the first error encountered.
+func (w *MarkdownWriter) setErr(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+var markdownTests = []struct {
+	Name   string
+	Align  []Alignment
+	Input  [][]string
+	Output string
+}{
+	{
+		Name:   "Simple",
+		Input:  [][]string{{"a", "b"}, {"1", "2"}},
+		Output: "| a | b |\n| --- | --- |\n| 1 | 2 |\n",
+	},
+	{
+		Name:   "Align",
+		Align:  []Alignment{AlignLeft, AlignCenter, AlignRight},
+		Input:  [][]string{{"a", "b", "c", "d"}},
+		Output: "| a | b | c | d |\n| :-- | :-: | --: | --- |\n",
+	},
+	{
+		Name:   "Escape",
+		Input:  [][]string{{"a|b", `c\d`}, {"e\r\nf", "g\nh\ri"}},
+		Output: "| a\\|b | c\\\\d |\n| --- | --- |\n| e<br>f | g<br>h<br>i |\n",
+	},
+}
+
+func TestMarkdownWriter(t *testing.T) {
+	for _, tt := range markdownTests {
+		b := &bytes.Buffer{}
+		w := NewMarkdownWriter(b)
+		w.Align = tt.Align
+		for _, row := range tt.Input {
+			writeRow(w, row)
+		}
+		w.Flush()
+		if err := w.Err(); err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.Name, err)
+		}
+		if out := b.String(); out != tt.Output {
+			t.Errorf("%s: out=%q want %q", tt.Name, out, tt.Output)
+		}
+	}
+}
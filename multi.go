@@ -0,0 +1,174 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MultiFileOptions controls NewMultiFileReader behaviour.
+type MultiFileOptions struct {
+	Dialect Dialect // dialect of all files (comma separated and quoted values when Sep is 0)
+	Header  bool    // files start with a header row, which is skipped except for the first file
+}
+
+// SourceError reports an error encountered while reading one input of a multi-file Reader.
+type SourceError struct {
+	Source string // file name
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return e.Source + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// NewMultiFileReader returns a Reader reading the files (opened with Zopen) in sequence as one logical stream.
+// Files are opened when needed and closed once consumed (use Close to release the current one when stopping early).
+// LineNumber is relative to the current file and parsing errors are wrapped in a *SourceError with the file name.
+// Fields streamed by FieldReader and records skipped by SkipRestOfRecord do not span files.
+func NewMultiFileReader(paths []string, opts *MultiFileOptions) *Reader {
+	if opts == nil {
+		opts = &MultiFileOptions{}
+	}
+	d := opts.Dialect
+	if d.Sep == 0 {
+		d.Sep, d.Quoted = ',', true
+	}
+	paths = append([]string(nil), paths...)
+	s := NewReaderDialect(strings.NewReader(""), d)
+	s.input = nil
+	s.paths = paths
+	s.next = func() (string, io.ReadCloser, error) {
+		if len(paths) == 0 {
+			return "", nil, io.EOF
+		}
+		path := paths[0]
+		paths = paths[1:]
+		rc, err := Zopen(path)
+		return path, rc, err
+	}
+	s.skipHeader = opts.Header
+	return s
+}
+
+// OpenGlob returns a multi-file Reader (see NewMultiFileReader) reading all files matching pattern
+// (see filepath.Match) in lexical order, like "data/2024-*.csv.gz".
+// When pattern is a directory, all its regular files are read (directories are always ignored).
+// Compressed files are detected by Zopen.
+func OpenGlob(pattern string, opts *MultiFileOptions) (*Reader, error) {
+	var paths []string
+	if fi, err := os.Stat(pattern); err == nil && fi.IsDir() {
+		fis, err := ioutil.ReadDir(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, fi := range fis {
+			if fi.Mode().IsRegular() {
+				paths = append(paths, filepath.Join(pattern, fi.Name()))
+			}
+		}
+	} else {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range matches {
+			if fi, err := os.Stat(path); err == nil && fi.Mode().IsRegular() { // directories are ignored
+				paths = append(paths, path)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("yacr: no file matching %q", pattern)
+	}
+	return NewMultiFileReader(paths, opts), nil
+}
+
+// nextSource switches to the next input of a multi-file Reader (skipping its header when needed).
+// It returns false when there is no more input or on error (reported by Err).
+func (s *Reader) nextSource() bool {
+	if s.next == nil || s.srcErr != nil {
+		return false
+	}
+	if s.input != nil {
+		done, size := s.inputProgress()
+		if size < 0 {
+			size = done
+		}
+		s.consumed += size
+		s.input = nil
+	}
+	if err := s.Close(); err != nil {
+		s.srcErr = &SourceError{Source: s.source, Err: err}
+		return false
+	}
+	name, rc, err := s.next()
+	if err != nil {
+		if err != io.EOF {
+			s.srcErr = err
+		}
+		return false
+	}
+	s.source, s.closer, s.input = name, rc, rc
+	s.sources++
+	if s.guessCharset {
+		s.Scanner = bufio.NewScanner(s.sniffCharset(rc))
+	} else {
+		s.Scanner = bufio.NewScanner(rc)
+	}
+	if s.bufSize > 0 {
+		s.Buffer(make([]byte, 0, s.bufSize), s.maxSize)
+	} else {
+		s.Buffer(nil, s.maxSize)
+	}
+	s.Split(s.ScanField)
+	s.eor, s.lineno, s.offset, s.nl, s.stream = true, 1, 0, 0, streamOff
+	if s.skipHeader && s.sources > 1 {
+		started := false // true once the first field of the header has been skipped
+		for s.Scanner.Scan() {
+			if !s.eor {
+				started = true
+			} else if started || len(s.Scanner.Bytes()) > 0 { // empty lines before the header are skipped
+				break
+			}
+		}
+	}
+	return true
+}
+
+// Err returns the first non-EOF error that was encountered by the Reader
+// (wrapped in a *SourceError by multi-file Readers).
+func (s *Reader) Err() error {
+	if s.srcErr != nil {
+		return s.srcErr
+	}
+	err := s.Scanner.Err()
+	if err == nil || s.source == "" {
+		return err
+	}
+	return &SourceError{Source: s.source, Err: err}
+}
+
+// Close closes the current file of a multi-file Reader (see NewMultiFileReader).
+// It does nothing for other Readers.
+func (s *Reader) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	err := s.closer.Close()
+	s.closer = nil
+	return err
+}
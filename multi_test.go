@@ -0,0 +1,155 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+// writeFiles creates files (gzipped when their name ends with .gz) in a new temporary directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "yacr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		data := []byte(content)
+		if filepath.Ext(name) == ".gz" {
+			var b bytes.Buffer
+			zw := gzip.NewWriter(&b)
+			zw.Write(data)
+			zw.Close()
+			data = b.Bytes()
+		}
+		if err = ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMultiFileReader(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.csv":    "id,name\n1,a\n2,b",
+		"b.csv.gz": "\nid,name\n3,c\n",
+		"c.csv":    "",
+		"d.csv":    "id,name\n4,\"d\n",
+	})
+	defer os.RemoveAll(dir)
+	var paths []string
+	for _, name := range []string{"a.csv", "b.csv.gz", "c.csv", "d.csv"} {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	r := NewMultiFileReader(paths, &MultiFileOptions{Header: true})
+	defer r.Close()
+	var got [][]string
+	var err error
+	for {
+		var values []string
+		if values, err = r.Record(); err != nil {
+			break
+		}
+		got = append(got, values)
+	}
+	want := [][]string{{"id", "name"}, {"1", "a"}, {"2", "b"}, {"3", "c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	var se *SourceError
+	if !errors.As(err, &se) || se.Source != paths[3] {
+		t.Fatalf("got %v; want *SourceError", err)
+	}
+	if r.LineNumber() != 2 {
+		t.Errorf("got line %d; want 2", r.LineNumber())
+	}
+
+	r = NewMultiFileReader([]string{paths[0], filepath.Join(dir, "missing.csv")}, nil)
+	n := 0
+	for err = nil; err == nil; n++ {
+		_, err = r.Record()
+	}
+	if n != 4 || !os.IsNotExist(errors.Unwrap(err)) && !os.IsNotExist(err) {
+		t.Errorf("got %v after %d records", err, n-1)
+	}
+}
+
+func TestOpenGlob(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"2024-02.csv.gz": "id\n2\n",
+		"2024-01.csv":    "id\n1\n",
+		"2023-12.csv":    "id\n0\n",
+	})
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "2024-dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		pattern string
+		want    [][]string
+	}{
+		{filepath.Join(dir, "2024-*"), [][]string{{"id"}, {"1"}, {"2"}}},
+		{dir, [][]string{{"id"}, {"0"}, {"1"}, {"2"}}},
+		{filepath.Join(dir, "2025-*"), nil},
+	}
+	for _, test := range tests {
+		r, err := OpenGlob(test.pattern, &MultiFileOptions{Header: true})
+		if err != nil {
+			if test.want != nil {
+				t.Errorf("%s: %v", test.pattern, err)
+			}
+			continue
+		} else if test.want == nil {
+			t.Errorf("%s: error expected", test.pattern)
+		}
+		var got [][]string
+		for {
+			values, err := r.Record()
+			if err != nil {
+				break
+			}
+			got = append(got, values)
+		}
+		if err = r.Err(); err != nil {
+			t.Errorf("%s: %v", test.pattern, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q; want %q", test.pattern, got, test.want)
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a.csv": "id\n1\n2\n", "b.csv": "id\n3\n"})
+	defer os.RemoveAll(dir)
+	r, err := OpenGlob(filepath.Join(dir, "*.csv"), &MultiFileOptions{Header: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	w := NewWriter(&b, ',', true)
+	if _, err = Copy(w, r, SourceColumn(r, "file")); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	a, c := filepath.Join(dir, "a.csv"), filepath.Join(dir, "b.csv")
+	if want := "id,file\n1," + a + "\n2," + a + "\n3," + c + "\n"; b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+	if r.Source() != c {
+		t.Errorf("got %q; want %q", r.Source(), c)
+	}
+	if DefaultReader(bytes.NewBufferString("a")).Source() != "" {
+		t.Error("no source expected")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package parquetcsv streams yacr records into Parquet row groups.
+package parquetcsv
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gwenn/yacr"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Column describes the Parquet column receiving one CSV field.
+type Column struct {
+	Name string
+	Node parquet.Node // e.g. parquet.String(), parquet.Optional(parquet.Int(64)), parquet.Date()
+}
+
+// Sink converts records to typed Parquet rows (it implements yacr.RecordSink).
+// Empty fields are converted to nulls for optional columns.
+// Row groups are flushed according to the writer options (see parquet.MaxRowsPerRowGroup) or by calling Flush.
+type Sink struct {
+	w      *parquet.Writer
+	leaves []parquet.LeafColumn // by CSV field index
+	rows   []parquet.Row        // one reusable row
+	recno  int                  // number of records written
+}
+
+var _ yacr.RecordSink = (*Sink)(nil)
+
+// NewSink returns a new Parquet sink writing to w.
+func NewSink(w io.Writer, columns []Column, options ...parquet.WriterOption) *Sink {
+	group := make(parquet.Group, len(columns))
+	for _, c := range columns {
+		group[c.Name] = c.Node
+	}
+	schema := parquet.NewSchema("csv", group)
+	leaves := make([]parquet.LeafColumn, len(columns))
+	for i, c := range columns {
+		leaves[i], _ = schema.Lookup(c.Name)
+	}
+	options = append([]parquet.WriterOption{schema}, options...)
+	return &Sink{w: parquet.NewWriter(w, options...), leaves: leaves,
+		rows: []parquet.Row{make(parquet.Row, len(columns))}}
+}
+
+// WriteFields converts one record to a Parquet row.
+func (s *Sink) WriteFields(fields [][]byte) error {
+	s.recno++
+	if len(fields) != len(s.leaves) {
+		return fmt.Errorf("record %d: got %d field(s), want %d", s.recno, len(fields), len(s.leaves))
+	}
+	row := s.rows[0]
+	for i, field := range fields {
+		leaf := s.leaves[i]
+		v, err := value(leaf, field)
+		if err != nil {
+			return fmt.Errorf("record %d, column %q: %v", s.recno, leaf.Path[0], err)
+		}
+		row[leaf.ColumnIndex] = v
+	}
+	_, err := s.w.WriteRows(s.rows)
+	return err
+}
+
+// Flush flushes the current row group.
+func (s *Sink) Flush() error {
+	return s.w.Flush()
+}
+
+// Close flushes the pending rows and writes the Parquet footer.
+// It does not close the underlying writer.
+func (s *Sink) Close() error {
+	return s.w.Close()
+}
+
+func value(leaf parquet.LeafColumn, field []byte) (parquet.Value, error) {
+	if len(field) == 0 && leaf.MaxDefinitionLevel > 0 {
+		return parquet.NullValue().Level(0, 0, leaf.ColumnIndex), nil
+	}
+	var v parquet.Value
+	t := leaf.Node.Type()
+	switch t.Kind() {
+	case parquet.Boolean:
+		b, err := strconv.ParseBool(string(field))
+		if err != nil {
+			return v, err
+		}
+		v = parquet.BooleanValue(b)
+	case parquet.Int32:
+		if lt := t.LogicalType(); lt != nil && lt.Date != nil {
+			d, err := time.Parse("2006-01-02", string(field))
+			if err != nil {
+				return v, err
+			}
+			v = parquet.Int32Value(int32(d.Unix() / 86400))
+			break
+		}
+		i, err := strconv.ParseInt(string(field), 10, 32)
+		if err != nil {
+			return v, err
+		}
+		v = parquet.Int32Value(int32(i))
+	case parquet.Int64:
+		i, err := strconv.ParseInt(string(field), 10, 64)
+		if err != nil {
+			return v, err
+		}
+		v = parquet.Int64Value(i)
+	case parquet.Float:
+		f, err := strconv.ParseFloat(string(field), 32)
+		if err != nil {
+			return v, err
+		}
+		v = parquet.FloatValue(float32(f))
+	case parquet.Double:
+		f, err := strconv.ParseFloat(string(field), 64)
+		if err != nil {
+			return v, err
+		}
+		v = parquet.DoubleValue(f)
+	case parquet.ByteArray:
+		v = parquet.ByteArrayValue(field)
+	default:
+		return v, fmt.Errorf("unsupported column type: %s", t)
+	}
+	return v.Level(0, leaf.MaxDefinitionLevel, leaf.ColumnIndex), nil
+}
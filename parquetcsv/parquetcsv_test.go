@@ -0,0 +1,68 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parquetcsv_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gwenn/yacr"
+	. "github.com/gwenn/yacr/parquetcsv"
+	"github.com/parquet-go/parquet-go"
+)
+
+type row struct {
+	Name  string  `parquet:"name"`
+	Count *int64  `parquet:"count,optional"`
+	Price float64 `parquet:"price"`
+	Ok    bool    `parquet:"ok"`
+}
+
+func TestSink(t *testing.T) {
+	columns := []Column{
+		{Name: "name", Node: parquet.String()},
+		{Name: "count", Node: parquet.Optional(parquet.Int(64))},
+		{Name: "price", Node: parquet.Leaf(parquet.DoubleType)},
+		{Name: "ok", Node: parquet.Leaf(parquet.BooleanType)},
+	}
+	input := "a,1,3.14,true\n\"b,c\",,0.5,false\nd,3,1,true\n"
+	b := &bytes.Buffer{}
+	s := NewSink(b, columns, parquet.MaxRowsPerRowGroup(2))
+	n, err := yacr.Copy(s, yacr.DefaultReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("got %d records; want %d", n, 3)
+	}
+
+	r := parquet.NewGenericReader[row](bytes.NewReader(b.Bytes()))
+	rows := make([]row, 4)
+	n2, err := r.Read(rows)
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	one, three := int64(1), int64(3)
+	want := []row{{"a", &one, 3.14, true}, {"b,c", nil, 0.5, false}, {"d", &three, 1, true}}
+	if !reflect.DeepEqual(rows[:n2], want) {
+		t.Errorf("got %+v; want %+v", rows[:n2], want)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	columns := []Column{{Name: "count", Node: parquet.Int(64)}}
+	for _, input := range []string{"x\n", "1,2\n"} {
+		s := NewSink(io.Discard, columns)
+		if _, err := yacr.Copy(s, yacr.DefaultReader(strings.NewReader(input))); err == nil {
+			t.Errorf("%q: error expected", input)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"io"
+	"os"
+)
+
+// Progress returns the number of bytes of the input consumed so far and the input size (-1 when unknown),
+// to display a percentage or estimate the remaining time of long reads.
+// The size is known for files (including the ones opened by Zopen, NewMultiFileReader and OpenGlob)
+// and for inputs implementing a Size() int64 method (like bytes.Reader and strings.Reader).
+// For compressed files, compressed bytes (read ahead by the decompressor) are counted.
+func (s *Reader) Progress() (done, total int64) {
+	done, total = s.inputProgress()
+	if s.next == nil {
+		return done, total
+	}
+	done += s.consumed
+	if s.paths == nil {
+		return done, -1
+	}
+	if s.pathsSize == 0 {
+		s.pathsSize = -1
+		var size int64
+		for _, path := range s.paths {
+			fi, err := os.Stat(path)
+			if err != nil {
+				return done, -1
+			}
+			size += fi.Size()
+		}
+		s.pathsSize = size
+	}
+	return done, s.pathsSize
+}
+
+// inputProgress returns the progress on the current input.
+func (s *Reader) inputProgress() (done, total int64) {
+	if s.input == nil { // between two files of a multi-file Reader
+		return 0, -1
+	}
+	done, total = s.offset, -1
+	switch in := s.input.(type) {
+	case *zReadCloser:
+		if in.rd != io.ReadCloser(in.f) {
+			done = in.n
+		}
+		total = fileSize(in.f)
+	case *os.File:
+		total = fileSize(in)
+	case interface{ Size() int64 }:
+		total = in.Size()
+	}
+	if total >= 0 && done > total {
+		done = total
+	}
+	return done, total
+}
+
+// fileSize returns the size of a regular file (-1 otherwise).
+func fileSize(f *os.File) int64 {
+	if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+		return fi.Size()
+	}
+	return -1
+}
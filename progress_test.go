@@ -0,0 +1,52 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestProgress(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,b\nc,d\n"))
+	if done, total := r.Progress(); done != 0 || total != 8 {
+		t.Errorf("got (%d, %d); want (0, 8)", done, total)
+	}
+	r.Record()
+	if done, total := r.Progress(); done != 4 || total != 8 {
+		t.Errorf("got (%d, %d); want (4, 8)", done, total)
+	}
+	if _, total := DefaultReader(bytes.NewBufferString("a")).Progress(); total != -1 {
+		t.Errorf("got %d; want -1", total)
+	}
+
+	content := strings.Repeat("1,2,3\n", 1000)
+	dir := writeFiles(t, map[string]string{"a.csv": content, "b.csv.gz": content})
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a.csv"), filepath.Join(dir, "b.csv.gz")
+	fa, _ := os.Stat(a)
+	fb, _ := os.Stat(b)
+	size := fa.Size() + fb.Size()
+	r = NewMultiFileReader([]string{a, b}, nil)
+	var last int64
+	for i := 0; ; i++ {
+		done, total := r.Progress()
+		if total != size || done < last || done > total {
+			t.Fatalf("record %d: got (%d, %d); want total %d", i, done, total, size)
+		}
+		last = done
+		if _, err := r.Record(); err != nil {
+			break
+		}
+	}
+	if last != size {
+		t.Errorf("got %d; want %d", last, size)
+	}
+}
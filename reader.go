@@ -8,11 +8,17 @@ package yacr
 import (
 	"bufio"
 	"bytes"
+	"database/sql"
 	"encoding"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"regexp"
 	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // Reader provides an interface for reading CSV data
@@ -21,17 +27,101 @@ import (
 // The EndOfRecord method tells when a field is terminated by a line break.
 type Reader struct {
 	*bufio.Scanner
-	sep    byte // values separator
-	quoted bool // specify if values may be quoted (when they contain separator or newline)
-	guess  bool // try to guess separator based on the file header
-	eor    bool // true when the most recent field has been terminated by a newline (not a separator).
-	lineno int  // current line number (not record number)
+	sep     byte // values separator
+	quoted  bool // specify if values may be quoted (when they contain separator or newline)
+	guess   bool // try to guess separator based on the file header
+	probed  bool // true when quoting has been detected (see GuessQuoted)
+	eor     bool // true when the most recent field has been terminated by a newline (not a separator).
+	lineno  int  // current line number (not record number)
+	recno   int  // current record number (empty lines excluded)
+	col     int  // index of the most recent field in the current record
+	stream  int  // state of the field streamed by FieldReader (or of the record skipped by SkipRestOfRecord)
+	bufSize int  // capacity of the initial buffer (see Buffer)
+	maxSize int  // maximum size of a field (see Buffer)
+	width   int  // number of fields of the first record (see OnRaggedRecord)
 
-	Trim    bool // trim spaces (only on unquoted values). Break rfc4180 rule: "Spaces are considered part of a field and should not be ignored."
-	Comment byte // character marking the start of a line comment. When specified (not 0), line comment appears as empty line.
-	Lazy    bool // specify if quoted values may contains unescaped quote not followed by a separator or a newline
+	truncating bool   // true while the rest of an oversized field is skipped (see TruncateFields)
+	trunc      []byte // first MaxFieldSize bytes of the oversized field
+	literal    bool   // true when the opening quote of the current field is scanned as data (see MaxQuotedLines)
+	dflt       []byte // default value of the current (empty) field (see Defaults)
+
+	decoders []func([]byte) ([]byte, error) // by column index (see SetColumnDecoder)
+
+	guessCharset bool    // specify if the input encoding must be detected (see GuessCharset)
+	charset      Charset // detected input encoding
+
+	widths []int // widths of the fields of a fixed-width Reader (see NewFixedReader)
+	fixed  int   // index of the next fixed-width field in the current record
+
+	last     []string // last record returned by Record (see UnreadRecord)
+	header   []string // raw header record (see ReadHeader)
+	pending  [][]byte // fields of the unread record not yet rescanned
+	replay   []byte   // current field when it comes from an unread record
+	text     string   // current field converted by Text (valid when cached is true)
+	cached   bool     // true when text matches the current field
+	nl       int      // offset (+1) of the next newline in the unscanned data (0 when unknown)
+	offset   int64    // number of bytes consumed by ScanField
+	recBytes int64    // number of bytes of the current record consumed by ScanField
+	total    int      // number of records started (empty lines excluded) seen by ScanField
+	lf       int      // number of records terminated by \n
+	crlf     int      // number of records terminated by \r\n
+
+	input      io.Reader                             // current input (see Progress)
+	consumed   int64                                 // size of the inputs already consumed by a multi-file Reader
+	paths      []string                              // files read by a multi-file Reader (nil when unknown)
+	pathsSize  int64                                 // total size of paths (0 when not yet computed, -1 when unknown)
+	next       func() (string, io.ReadCloser, error) // opens the next input of a multi-file Reader (io.EOF when there is none)
+	source     string                                // name of the current input of a multi-file Reader
+	closer     io.Closer                             // current input of a multi-file Reader (nil once closed)
+	sources    int                                   // number of inputs opened
+	skipHeader bool                                  // true when the header of inputs (except the first one) must be skipped
+	srcErr     error                                 // error encountered while switching inputs (or decoding a field, see SetColumnDecoder)
+
+	single   recordStorage   // storage reused by ReadRecord
+	storages []recordStorage // storages reused by ReadBatch
+	records  [][][]byte      // batch reused by ReadBatch
+	columns  Columns         // batch reused by ReadColumns
+
+	Trim            bool  // trim spaces (only on unquoted values). Break rfc4180 rule: "Spaces are considered part of a field and should not be ignored."
+	Comment         byte  // character marking the start of a line comment. When specified (not 0), line comment appears as empty line.
+	Lazy            bool  // specify if quoted values may contains unescaped quote not followed by a separator or a newline
+	GuessQuoted     bool  // specify if quoting (rfc4180) usage must be detected from the first buffer (overriding quoted)
+	Strict          bool  // specify if ScanRecord fails with an *ArityError when the number of fields differs from the number of values
+	MaxRecordBytes  int64 // when positive, maximum size (in bytes) of a record (a *ParseError wrapping ErrRecordTooLarge is reported otherwise)
+	MaxRecordsTotal int   // when positive, maximum number of records (a *ParseError wrapping ErrTooManyRecords is reported otherwise)
+	FieldsPerRecord int   // when positive, number of fields of each record (a *ParseError wrapping an *ArityError is reported by Scan otherwise). Zero or negative disables the check.
+	MaxFieldSize    int   // when positive, maximum size (in bytes) of a field (a *ParseError wrapping ErrFieldTooLarge is reported otherwise)
+	TruncateFields  bool  // specify if fields exceeding MaxFieldSize are truncated (without being loaded in memory) instead of failing
+	MaxQuotedLines  int   // when positive, a quoted value spanning more lines is assumed to start with a stray quote, scanned as an unquoted value
+	ValidateUTF8    bool  // specify if fields must be valid UTF-8 (a *ParseError wrapping ErrInvalidUTF8 is reported otherwise). Streamed fields are not checked.
+
+	SmartQuotes    bool           // specify if typographic quotes (“ ” „) in values must be replaced by plain double quotes (not in streamed fields)
+	Quote          byte           // character enclosing quoted values (escaped by doubling it). Double quote when 0.
+	Escape         byte           // character escaping a separator, a newline or itself in unquoted values (like '\\') and any character in quoted values (\n and \r standing for a newline and a carriage return, see Writer.Escape). Disabled when 0.
+	QuotedNewlines Newlines       // how line breaks inside quoted values are returned (NewlinesKeep by default)
+	Interner       *Interner      // when specified, strings returned by Text (and Record, Strings, ScanRecord...) are interned
+	Bools          *BoolTokens    // tokens accepted for booleans (instead of strconv.ParseBool syntax)
+	TimeLayouts    []string       // layouts tried (in order) to scan times (RFC3339, "2006-01-02 15:04:05" and "2006-01-02" when nil)
+	Nulls          []string       // tokens (like "", `\N` or "NULL") scanned as nil into sql.Scanner values (like *sql.NullString) and pointers to pointers
+	SepRegexp      *regexp.Regexp // when specified, fields are delimited by matches of this expression (instead of sep), quoting and comments are not supported
+	SepString      string         // when specified, fields are delimited by this (multi-byte) string, like "||" (instead of sep), comments and Escape are not supported
+
+	// OnRaggedRecord, when specified, is called each time a record has a number of fields different from the first one
+	// (records skipped by SkipRestOfRecord or streamed by FieldReader excepted), without failing the read.
+	OnRaggedRecord func(recordNum, got, want int)
+	// OnResync, when specified, enables the recovery of malformed quoted fields (non-terminated or followed by an unescaped quote):
+	// the input is skipped from the opening quote to the first line followed by well-formed records
+	// (balanced quotes and the same number of fields as the first record),
+	// the current record is terminated by an empty field and OnResync is called with the skipped byte range [start, end).
+	OnResync func(start, end int64)
+	// OnTruncate, when specified, is called each time a field is truncated (see TruncateFields), before it is returned.
+	OnTruncate func(recordNum, column int)
+
+	Defaults       map[string]string // default values of empty fields by column name (see Headers)
+	ColumnDefaults []string          // default values of empty fields by column index (first is 0), overriding Defaults (empty strings are ignored)
 
 	Headers map[string]int // Index (first is 1) by header
+	Schema  Schema         // declared columns (by position) used by ScanAnyMap (types are inferred from values when nil)
 }
 
 // DefaultReader creates a "standard" CSV reader (separator is comma and quoted mode active)
@@ -39,14 +129,75 @@ func DefaultReader(rd io.Reader) *Reader {
 	return NewReader(rd, ',', true, false)
 }
 
+// maxTokenSize is the default maximum size of a field (unlimited, see Reader.Buffer).
+const maxTokenSize = int(^uint(0) >> 1)
+
 // NewReader returns a new CSV scanner to read from r.
 // When quoted is false, values must not contain a separator or newline.
+// Fields size is not limited (unlike bufio.Scanner tokens), use Buffer to set a maximum size.
 func NewReader(r io.Reader, sep byte, quoted, guess bool) *Reader {
-	s := &Reader{bufio.NewScanner(r), sep, quoted, guess, true, 1, false, 0, false, nil}
+	s := &Reader{Scanner: bufio.NewScanner(r), input: r, sep: sep, quoted: quoted, guess: guess, eor: true, lineno: 1}
+	s.Buffer(nil, maxTokenSize)
 	s.Split(s.ScanField)
 	return s
 }
 
+// Buffer sets the initial buffer and the maximum size of a field (see bufio.Scanner.Buffer).
+func (s *Reader) Buffer(buf []byte, max int) {
+	s.bufSize, s.maxSize = cap(buf), max
+	s.Scanner.Buffer(buf, max)
+}
+
+// CloneFor returns a new Reader parsing r like s: separator, quoting mode (guessed or not),
+// options and buffer sizing are copied but not the state (position, headers, unread record...).
+// When specified, the Interner is not shared (it is not safe for concurrent use) but a new one with the same capacity is created.
+// Useful to process many shards of the same dataset in parallel.
+func (s *Reader) CloneFor(r io.Reader) *Reader {
+	c := NewReader(r, s.sep, s.quoted, s.guess)
+	c.probed = s.probed
+	c.widths = s.widths
+	if s.bufSize > 0 {
+		c.Buffer(make([]byte, 0, s.bufSize), s.maxSize)
+	} else {
+		c.Buffer(nil, s.maxSize)
+	}
+	c.Trim = s.Trim
+	c.Comment = s.Comment
+	c.Lazy = s.Lazy
+	c.GuessQuoted = s.GuessQuoted
+	c.Strict = s.Strict
+	c.FieldsPerRecord = s.FieldsPerRecord
+	c.MaxRecordBytes = s.MaxRecordBytes
+	c.MaxRecordsTotal = s.MaxRecordsTotal
+	c.MaxFieldSize = s.MaxFieldSize
+	c.TruncateFields = s.TruncateFields
+	c.MaxQuotedLines = s.MaxQuotedLines
+	c.ValidateUTF8 = s.ValidateUTF8
+	c.SmartQuotes = s.SmartQuotes
+	c.Quote = s.Quote
+	c.Escape = s.Escape
+	c.QuotedNewlines = s.QuotedNewlines
+	if s.Interner != nil {
+		c.Interner = NewInterner(s.Interner.max)
+	}
+	c.Bools = s.Bools
+	c.TimeLayouts = s.TimeLayouts
+	c.Nulls = s.Nulls
+	c.SepRegexp = s.SepRegexp
+	c.SepString = s.SepString
+	c.OnRaggedRecord = s.OnRaggedRecord
+	c.OnResync = s.OnResync
+	c.OnTruncate = s.OnTruncate
+	c.Schema = s.Schema
+	c.Defaults = s.Defaults
+	c.ColumnDefaults = s.ColumnDefaults
+	c.decoders = append([]func([]byte) ([]byte, error)(nil), s.decoders...)
+	if s.guessCharset {
+		_ = c.GuessCharset() // cannot fail before the first Scan
+	}
+	return c
+}
+
 // ScanHeaders loads current line as the header line.
 func (s *Reader) ScanHeaders() error {
 	s.Headers = make(map[string]int)
@@ -59,6 +210,63 @@ func (s *Reader) ScanHeaders() error {
 	return s.Err()
 }
 
+// ReadHeader reads the next record (empty lines are skipped) as the header and returns it.
+// Headers is set accordingly, except that the first of duplicated names wins
+// (other columns with the same name remain accessible by position, see Header).
+func (s *Reader) ReadHeader() ([]string, error) {
+	header, err := s.Record()
+	if err != nil {
+		return nil, err
+	}
+	s.last = nil
+	s.header = header
+	s.Headers = make(map[string]int, len(header))
+	for i, name := range header {
+		if _, dup := s.Headers[name]; !dup {
+			s.Headers[name] = i + 1
+		}
+	}
+	return header, nil
+}
+
+// Header returns the raw header record read by ReadHeader (nil when not read).
+func (s *Reader) Header() []string {
+	return s.header
+}
+
+// FieldByName returns the field named name (see Headers) of the last record returned by Record.
+// It returns false when the name is unknown or the field is missing (use String to get the reason).
+func (s *Reader) FieldByName(name string) (string, bool) {
+	i, err := s.namedField(name)
+	if err != nil {
+		return "", false
+	}
+	return s.last[i-1], true
+}
+
+// Sentinel errors wrapped by ArityError (use errors.Is).
+var (
+	ErrTooFewFields  = errors.New("too few fields")
+	ErrTooManyFields = errors.New("too many fields")
+)
+
+// ArityError reports a record whose number of fields does not match the expected one.
+type ArityError struct {
+	Record int   // record number (first is 1)
+	Want   int   // expected number of fields (minimum for ErrTooFewFields)
+	Got    int   // actual number of fields
+	Err    error // ErrTooFewFields or ErrTooManyFields
+}
+
+func (e *ArityError) Error() string {
+	return fmt.Sprintf("%v in record %d: got %d; want %d", e.Err, e.Record, e.Got, e.Want)
+}
+
+// Unwrap returns ErrTooFewFields or ErrTooManyFields.
+func (e *ArityError) Unwrap() error {
+	return e.Err
+}
+
 // ScanRecordByName decodes one line fields by name (name1, value1, ...).
 // Specified names must match Headers.
 func (s *Reader) ScanRecordByName(args ...interface{}) (int, error) {
@@ -85,6 +293,8 @@ func (s *Reader) ScanRecordByName(args ...interface{}) (int, error) {
 // It's like fmt.Scan or database.sql.Rows.Scan.
 // Returns (0, nil) on EOF, (*, err) on error
 // and (n >= 1, nil) on success (n may be less or greater than len(values)).
+// When Strict is set, a record with less or more than len(values) fields
+// is reported by an *ArityError (wrapping ErrTooFewFields or ErrTooManyFields).
 //   var n int
 //   var err error
 //   for {
@@ -102,6 +312,16 @@ func (s *Reader) ScanRecordByName(args ...interface{}) (int, error) {
 //     // error handling
 //   }
 func (s *Reader) ScanRecord(values ...interface{}) (int, error) {
+	n, err := s.scanRecord(values)
+	if err != nil || n == 0 || !s.Strict || n == len(values) {
+		return n, err
+	} else if n < len(values) {
+		return n, &ArityError{Record: s.recno, Want: len(values), Got: n, Err: ErrTooFewFields}
+	}
+	return n, &ArityError{Record: s.recno, Want: len(values), Got: n, Err: ErrTooManyFields}
+}
+
+func (s *Reader) scanRecord(values []interface{}) (int, error) {
 	for i, value := range values {
 		if !s.Scan() {
 			return i, s.Err()
@@ -131,6 +351,28 @@ func (s *Reader) ScanRecord(values ...interface{}) (int, error) {
 	return len(values), nil
 }
 
+// ScanRecordPartial is like ScanRecord but only the first min values are mandatory:
+// trailing values missing from the record are reset to their zero value (instead of keeping their previous content).
+// Returns (0, nil) on EOF and an *ArityError (wrapping ErrTooFewFields) when the record has less than min fields.
+// Extra fields are ignored (even when Strict is set).
+func (s *Reader) ScanRecordPartial(min int, values ...interface{}) (int, error) {
+	n, err := s.scanRecord(values)
+	if err != nil || n == 0 {
+		return n, err
+	} else if n < min {
+		return n, &ArityError{Record: s.recno, Want: min, Got: n, Err: ErrTooFewFields}
+	}
+	for _, value := range values[n:] {
+		if value == nil {
+			continue
+		}
+		if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && !v.IsNil() {
+			v.Elem().Set(reflect.Zero(v.Elem().Type()))
+		}
+	}
+	return n, nil
+}
+
 // ScanValue advances to the next token and decodes field's content to value.
 // The value may point to data that will be overwritten by a subsequent call to Scan.
 func (s *Reader) ScanValue(value interface{}) error {
@@ -140,29 +382,326 @@ func (s *Reader) ScanValue(value interface{}) error {
 	return s.value(value, false)
 }
 
+// Record returns the next record as a fresh slice of strings.
+// Empty lines (and line comments) are skipped.
+// It returns (nil, io.EOF) when there is no more record.
+func (s *Reader) Record() ([]string, error) {
+	return s.ScanRow(nil)
+}
+
+// ScanRow returns the next record, appending its fields to dst[:0] (so that the caller's slice can be reused between calls).
+// Empty lines (and line comments) are skipped.
+// It returns (nil, io.EOF) when there is no more record.
+//   var row []string
+//   var err error
+//   for row, err = s.ScanRow(row); err == nil; row, err = s.ScanRow(row) {
+//     // ...
+//   }
+//   if err != io.EOF {
+//     // error handling
+//   }
+func (s *Reader) ScanRow(dst []string) ([]string, error) {
+	values := dst[:0]
+	for s.Scan() {
+		if len(values) == 0 && s.EndOfRecord() && len(s.Bytes()) == 0 { // skip empty line (or line comment)
+			continue
+		}
+		values = append(values, s.Text())
+		if s.EndOfRecord() {
+			s.last = values
+			return values, nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return values, err
+	} else if len(values) == 0 {
+		return nil, io.EOF
+	}
+	s.last = values
+	return values, nil
+}
+
+// UnreadRecord pushes back the last record returned by Record
+// so that its fields are returned again by the next read (Scan, ScanRecord, Record, ...).
+// Only one record can be pushed back.
+// FieldReader ignores the pushed back record.
+func (s *Reader) UnreadRecord() error {
+	if s.last == nil {
+		return errors.New("yacr.Reader: no record to unread")
+	}
+	s.pending = make([][]byte, len(s.last))
+	for i, value := range s.last {
+		s.pending[i] = []byte(value)
+	}
+	s.last = nil
+	s.recno--
+	return nil
+}
+
+// String returns the field named name (see Headers) of the last record returned by Record.
+// An unknown name or a missing field (ragged record) is reported as an error.
+func (s *Reader) String(name string) (string, error) {
+	i, err := s.namedField(name)
+	if err != nil {
+		return "", err
+	}
+	return s.last[i-1], nil
+}
+
+// Int returns the field named name (see Headers) of the last record returned by Record as an int.
+// A conversion failure is reported by a *FieldError.
+func (s *Reader) Int(name string) (int, error) {
+	i, err := s.namedField(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.Atoi(s.last[i-1])
+	if err != nil {
+		return 0, &FieldError{Record: s.recno, Column: i, Name: name, Text: s.last[i-1], Err: err}
+	}
+	return v, nil
+}
+
+// Float returns the field named name (see Headers) of the last record returned by Record as a float64.
+// A conversion failure is reported by a *FieldError.
+func (s *Reader) Float(name string) (float64, error) {
+	i, err := s.namedField(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(s.last[i-1], 64)
+	if err != nil {
+		return 0, &FieldError{Record: s.recno, Column: i, Name: name, Text: s.last[i-1], Err: err}
+	}
+	return v, nil
+}
+
+// ReadAllMaps reads the header with ReadHeader (unless Headers is already set) and returns all remaining records
+// as maps by column name. Like Headers, the first of duplicated names wins: the other columns with the same name,
+// extra fields and fields of unnamed columns are named by their column number (first is 1). Missing fields are absent.
+// Empty lines are skipped.
+// Intended for small inputs: each record is allocated.
+func ReadAllMaps(r *Reader) ([]map[string]string, error) {
+	if r.Headers == nil {
+		if _, err := r.ReadHeader(); err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	names := headerNames(r.Headers)
+	var maps []map[string]string
+	for {
+		values, err := r.Record()
+		if err == io.EOF {
+			return maps, nil
+		} else if err != nil {
+			return maps, err
+		}
+		m := make(map[string]string, len(values))
+		for i, value := range values {
+			if i < len(names) && names[i] != "" {
+				m[names[i]] = value
+			} else {
+				m[strconv.Itoa(i+1)] = value
+			}
+		}
+		maps = append(maps, m)
+	}
+}
+
+// headerNames returns the column names by position (empty when unknown, like duplicated names).
+func headerNames(headers map[string]int) []string {
+	var names []string
+	for name, i := range headers {
+		for len(names) < i {
+			names = append(names, "")
+		}
+		names[i-1] = name
+	}
+	return names
+}
+
+// namedField returns the index (first is 1) of the field named name in the last record returned by Record.
+func (s *Reader) namedField(name string) (int, error) {
+	if s.Headers == nil {
+		return 0, errors.New("yacr.Reader: no headers (see ScanHeaders)")
+	} else if s.last == nil {
+		return 0, errors.New("yacr.Reader: no record (see Record)")
+	}
+	i, ok := s.Headers[name]
+	if !ok {
+		return 0, fmt.Errorf("yacr.Reader: unknown column: %q", name)
+	} else if i > len(s.last) {
+		return 0, &FieldError{Record: s.recno, Column: i, Name: name, Err: ErrTooFewFields}
+	}
+	return i, nil
+}
+
+// Scan advances the Reader to the next field, which will then be available through the Bytes or Text method.
+// It returns false when the scan stops, either by reaching the end of the input or an error.
+func (s *Reader) Scan() bool {
+	if s.srcErr != nil {
+		return false
+	}
+	s.cached = false
+	start := s.eor
+	replayed := len(s.pending) > 0
+	line, offset := s.lineno, s.offset // start of the field
+	if replayed {
+		s.replay = s.pending[0]
+		s.pending = s.pending[1:]
+		s.eor = len(s.pending) == 0
+	} else {
+		s.replay = nil
+		for !s.Scanner.Scan() {
+			if s.Scanner.Err() != nil || !s.nextSource() {
+				return false
+			}
+			line, offset = s.lineno, s.offset
+		}
+	}
+	if !start {
+		s.col++
+	} else if s.col = 0; !s.eor || len(s.Bytes()) > 0 { // empty lines are not counted
+		s.recno++
+	} else {
+		return true
+	}
+	if s.eor && s.replay == nil {
+		if n := s.col + 1; s.FieldsPerRecord > 0 && n != s.FieldsPerRecord {
+			err := &ArityError{Record: s.recno, Want: s.FieldsPerRecord, Got: n, Err: ErrTooFewFields}
+			if n > s.FieldsPerRecord {
+				err.Err = ErrTooManyFields
+			}
+			s.srcErr = &ParseError{Line: line, Record: s.recno, Column: n, Offset: offset, Err: err}
+			return false
+		}
+		s.checkWidth(s.col + 1)
+	}
+	if len(s.Bytes()) == 0 && (s.Defaults != nil || s.ColumnDefaults != nil) {
+		s.defaultValue()
+	}
+	if s.col < len(s.decoders) && s.decoders[s.col] != nil && !replayed { // replayed fields are already decoded
+		value, err := s.decoders[s.col](s.Bytes())
+		if err != nil {
+			s.srcErr = s.fieldError(err)
+			return false
+		} else if value == nil {
+			value = []byte{}
+		}
+		s.replay = value
+	}
+	return true
+}
+
+// SetColumnDecoder registers a function transforming the fields of the column col (first is 0), like masking or normalization.
+// It is called by Scan (after the default value substitution, see Defaults) and its result is returned by Bytes, Text, ...
+// An error stops the scan and is reported by Err (as a *FieldError). A nil decoder unregisters the current one.
+func (s *Reader) SetColumnDecoder(col int, decoder func([]byte) ([]byte, error)) {
+	for len(s.decoders) <= col {
+		s.decoders = append(s.decoders, nil)
+	}
+	s.decoders[col] = decoder
+}
+
+// defaultValue replaces the current (empty) field by its column default value (see Defaults and ColumnDefaults), if any.
+func (s *Reader) defaultValue() {
+	var def string
+	if s.col < len(s.ColumnDefaults) && s.ColumnDefaults[s.col] != "" {
+		def = s.ColumnDefaults[s.col]
+	} else {
+		for name, value := range s.Defaults {
+			if s.Headers[name] == s.col+1 {
+				def = value
+				break
+			}
+		}
+	}
+	if def != "" {
+		s.dflt = append(s.dflt[:0], def...)
+		s.replay = s.dflt
+	}
+}
+
+// checkWidth compares the number of fields of the record just scanned with the width of the first one (see OnRaggedRecord).
+func (s *Reader) checkWidth(n int) {
+	if s.width == 0 {
+		s.width = n
+	} else if n != s.width && s.OnRaggedRecord != nil {
+		s.OnRaggedRecord(s.recno, n, s.width)
+	}
+}
+
+// Bytes returns the most recent field generated by a call to Scan.
+// The underlying array may point to data that will be overwritten by a subsequent call to Scan.
+func (s *Reader) Bytes() []byte {
+	if s.replay != nil {
+		return s.replay
+	}
+	return s.Scanner.Bytes()
+}
+
+// Text returns the most recent field generated by a call to Scan as a newly allocated (or interned) string.
+// The conversion is done once per field (subsequent calls return the same string).
+func (s *Reader) Text() string {
+	if s.cached {
+		return s.text
+	}
+	if s.Interner != nil {
+		s.text = s.Interner.Intern(s.Bytes())
+	} else if s.replay != nil {
+		s.text = string(s.replay)
+	} else {
+		s.text = s.Scanner.Text()
+	}
+	s.cached = true
+	return s.text
+}
+
+// Strings returns all the remaining fields of the current record (consuming through EndOfRecord).
+// It returns nil when the current record has already been fully consumed.
+func (s *Reader) Strings() ([]string, error) {
+	var values []string
+	for !s.EndOfRecord() {
+		if !s.Scan() {
+			return values, s.Err()
+		}
+		values = append(values, s.Text())
+	}
+	return values, nil
+}
+
 // Value decodes field's content to value.
 // The value may point to data that will be overwritten by a subsequent call to Scan.
 func (s *Reader) Value(value interface{}) error {
 	return s.value(value, false)
 }
 func (s *Reader) value(value interface{}, copied bool) error {
+	if s.Nulls != nil && s.isNull() {
+		if ok, err := s.scanNull(value); ok {
+			return err
+		}
+	}
 	var err error
 	switch value := value.(type) {
 	case nil:
 	case *string:
 		*value = s.Text()
 	case *int:
-		*value, err = strconv.Atoi(s.Text())
+		*value, err = strconv.Atoi(s.numText())
 	case *int32:
 		var i int64
-		i, err = strconv.ParseInt(s.Text(), 10, 32)
+		i, err = strconv.ParseInt(s.numText(), 10, 32)
 		*value = int32(i)
 	case *int64:
-		*value, err = strconv.ParseInt(s.Text(), 10, 64)
+		*value, err = strconv.ParseInt(s.numText(), 10, 64)
 	case *bool:
-		*value, err = strconv.ParseBool(s.Text())
+		*value, err = s.Bools.parse(s.numText())
 	case *float64:
-		*value, err = strconv.ParseFloat(s.Text(), 64)
+		*value, err = strconv.ParseFloat(s.numText(), 64)
+	case *time.Time:
+		*value, err = s.parseTime(s.numText())
 	case *[]byte:
 		if copied {
 			v := s.Bytes()
@@ -174,49 +713,221 @@ func (s *Reader) value(value interface{}, copied bool) error {
 		}
 	case encoding.TextUnmarshaler:
 		err = value.UnmarshalText(s.Bytes())
+	case sql.Scanner:
+		err = value.Scan(s.Text())
 	default:
 		return s.scanReflect(value)
 	}
-	return err
+	if err != nil {
+		return s.fieldError(err)
+	}
+	return nil
+}
+
+// isNull tells if the current field is a null token (see Nulls).
+func (s *Reader) isNull() bool {
+	field := s.Bytes()
+	for _, null := range s.Nulls {
+		if string(field) == null {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *Reader) scanReflect(v interface{}) (err error) {
+// scanNull sets value to nil when it is an sql.Scanner or a pointer to pointer.
+// It returns false for other types (decoded as usual).
+func (s *Reader) scanNull(value interface{}) (bool, error) {
+	if sc, ok := value.(sql.Scanner); ok {
+		if err := sc.Scan(nil); err != nil {
+			return true, s.fieldError(err)
+		}
+		return true, nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Ptr {
+		return false, nil
+	}
+	rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+	return true, nil
+}
+
+// parseTime parses text with the first matching layout (see TimeLayouts).
+func (s *Reader) parseTime(text string) (time.Time, error) {
+	layouts := s.TimeLayouts
+	if layouts == nil {
+		layouts = timeLayouts
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no time layout matches (tried %q)", layouts)
+}
+
+// numText returns the current field as a string to be parsed (without allocation).
+// Unless already converted by Text, the string shares the field storage so it must not be retained.
+func (s *Reader) numText() string {
+	if s.cached {
+		return s.text
+	}
+	return bytesString(s.Bytes())
+}
+
+// reflectPlan decodes the current field into dv (a settable value of the planned type).
+type reflectPlan func(s *Reader, dv reflect.Value) error
+
+// reflectPlans caches plans by destination pointer type (nil plan for unsupported types).
+var reflectPlans sync.Map
+
+func (s *Reader) scanReflect(v interface{}) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return fmt.Errorf("unsupported type %T", v)
 	}
-	dv := reflect.Indirect(rv)
-	switch dv.Kind() {
+	t := rv.Type()
+	plan, ok := reflectPlans.Load(t)
+	if !ok {
+		plan, _ = reflectPlans.LoadOrStore(t, newReflectPlan(t.Elem()))
+	}
+	p := plan.(reflectPlan)
+	if p == nil {
+		return fmt.Errorf("unsupported type: %T", v)
+	}
+	if err := p(s, rv.Elem()); err != nil {
+		if _, ok := err.(*FieldError); ok { // already wrapped (pointer to pointer)
+			return err
+		}
+		return s.fieldError(err)
+	}
+	return nil
+}
+
+// newReflectPlan builds the plan decoding fields into values of type t (nil when unsupported).
+func newReflectPlan(t reflect.Type) reflectPlan {
+	switch t.Kind() {
 	case reflect.String:
-		dv.SetString(s.Text())
+		return func(s *Reader, dv reflect.Value) error {
+			dv.SetString(s.Text())
+			return nil
+		}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		var i int64
-		i, err = strconv.ParseInt(s.Text(), 10, dv.Type().Bits())
-		if err == nil {
-			dv.SetInt(i)
+		bits := t.Bits()
+		return func(s *Reader, dv reflect.Value) error {
+			i, err := strconv.ParseInt(s.numText(), 10, bits)
+			if err == nil {
+				dv.SetInt(i)
+			}
+			return err
 		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		var i uint64
-		i, err = strconv.ParseUint(s.Text(), 10, dv.Type().Bits())
-		if err == nil {
-			dv.SetUint(i)
+		bits := t.Bits()
+		return func(s *Reader, dv reflect.Value) error {
+			i, err := strconv.ParseUint(s.numText(), 10, bits)
+			if err == nil {
+				dv.SetUint(i)
+			}
+			return err
 		}
 	case reflect.Bool:
-		var b bool
-		b, err = strconv.ParseBool(s.Text())
-		if err == nil {
-			dv.SetBool(b)
+		return func(s *Reader, dv reflect.Value) error {
+			b, err := s.Bools.parse(s.numText())
+			if err == nil {
+				dv.SetBool(b)
+			}
+			return err
 		}
 	case reflect.Float32, reflect.Float64:
-		var f float64
-		f, err = strconv.ParseFloat(s.Text(), dv.Type().Bits())
-		if err == nil {
-			dv.SetFloat(f)
+		bits := t.Bits()
+		return func(s *Reader, dv reflect.Value) error {
+			f, err := strconv.ParseFloat(s.numText(), bits)
+			if err == nil {
+				dv.SetFloat(f)
+			}
+			return err
+		}
+	case reflect.Ptr: // allocated and decoded like any other value (nil for null tokens, see Nulls)
+		elem := t.Elem()
+		return func(s *Reader, dv reflect.Value) error {
+			p := reflect.New(elem)
+			if err := s.value(p.Interface(), true); err != nil {
+				return err
+			}
+			dv.Set(p)
+			return nil
 		}
-	default:
-		return fmt.Errorf("unsupported type: %T", v)
 	}
-	return
+	return nil
+}
+
+// Sentinel errors wrapped by ParseError (use errors.Is).
+var (
+	ErrInvalidUTF8    = errors.New("invalid UTF-8 sequence") // a field is not valid UTF-8 (see ValidateUTF8)
+	ErrRecordTooLarge = errors.New("record too large")       // a record exceeds MaxRecordBytes
+	ErrTooManyRecords = errors.New("too many records")       // the input exceeds MaxRecordsTotal
+	ErrFieldTooLarge  = errors.New("field too large")        // a field exceeds MaxFieldSize (unless TruncateFields is set)
+)
+
+// ParseError reports an invalid input with its position.
+type ParseError struct {
+	Line   int   // line number where the field starts
+	Record int   // record number (first is 1)
+	Column int   // field number (first is 1)
+	Offset int64 // byte offset of the field in the input
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d (record %d, offset %d): %v", e.Line, e.Column, e.Record, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError reports a field whose content cannot be converted.
+type FieldError struct {
+	Record int    // record number (first is 1)
+	Column int    // column number (first is 1)
+	Name   string // column name (empty when headers are unknown)
+	Text   string // offending content
+	Err    error  // conversion error
+}
+
+func (e *FieldError) Error() string {
+	var col string
+	if e.Name != "" {
+		col = strconv.Quote(e.Name)
+	} else {
+		col = strconv.Itoa(e.Column)
+	}
+	err := e.Err
+	if ne, ok := err.(*strconv.NumError); ok {
+		err = ne.Err
+	}
+	return fmt.Sprintf("column %s, record %d: %v for %q", col, e.Record, err, e.Text)
+}
+
+// Unwrap returns the conversion error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldError wraps err with the current field context.
+func (s *Reader) fieldError(err error) error {
+	e := &FieldError{Record: s.recno, Column: s.col + 1, Text: s.Text(), Err: err}
+	if ne, ok := err.(*strconv.NumError); ok {
+		ne.Num = e.Text // may share the field storage (see numText)
+	}
+	for name, i := range s.Headers {
+		if i == e.Column {
+			e.Name = name
+			break
+		}
+	}
+	return e
 }
 
 // LineNumber returns current line number (not record number)
@@ -224,6 +935,39 @@ func (s *Reader) LineNumber() int {
 	return s.lineno
 }
 
+// Source returns the name of the input of the most recent field for multi-file Readers (see NewMultiFileReader, OpenGlob, WatchDir).
+// It returns an empty string for other Readers.
+func (s *Reader) Source() string {
+	return s.source
+}
+
+// InputOffset returns the input stream byte offset of the end of the most recent field
+// (the separator or newline terminating it included).
+func (s *Reader) InputOffset() int64 {
+	return s.offset
+}
+
+// RecordNumber returns the number of the current record (first is 1), empty lines excluded.
+func (s *Reader) RecordNumber() int {
+	return s.recno
+}
+
+// LineEndings counts the record terminators read so far.
+type LineEndings struct {
+	LF   int // records terminated by \n
+	CRLF int // records terminated by \r\n
+}
+
+// Mixed tells if both kinds of terminators have been read.
+func (l LineEndings) Mixed() bool {
+	return l.LF > 0 && l.CRLF > 0
+}
+
+// LineEndings returns the record terminators read so far (the last record may have no terminator).
+func (s *Reader) LineEndings() LineEndings {
+	return LineEndings{LF: s.lf, CRLF: s.crlf}
+}
+
 // EndOfRecord returns true when the most recent field has been terminated by a newline (not a separator).
 func (s *Reader) EndOfRecord() bool {
 	return s.eor
@@ -234,6 +978,14 @@ func (s *Reader) Sep() byte {
 	return s.sep
 }
 
+// GuessNext re-arms separator guessing (and quoting detection when GuessQuoted is set):
+// the separator is guessed again from the data following the current field
+// (useful when the input is a concatenation of sources with different dialects).
+func (s *Reader) GuessNext() {
+	s.guess = true
+	s.probed = false
+}
+
 // SkipRecords skips n records/headers
 func (s *Reader) SkipRecords(n int) error {
 	i := 0
@@ -244,15 +996,138 @@ func (s *Reader) SkipRecords(n int) error {
 		if !s.Scan() {
 			return s.Err()
 		}
-		if s.eor {
-			i++
+		if err := s.SkipRestOfRecord(); err != nil {
+			return err
 		}
+		i++
+	}
+}
+
+// readRecord reads the next record (skipping empty lines) into buf and fields, reusing their storage.
+// It returns a nil fields slice on EOF.
+func (s *Reader) readRecord(buf []byte, fields [][]byte) ([]byte, [][]byte, error) {
+	buf = buf[:0]
+	fields = fields[:0]
+	for s.Scan() {
+		if len(fields) == 0 && s.EndOfRecord() && len(s.Bytes()) == 0 { // skip empty line (or line comment)
+			continue
+		}
+		start := len(buf)
+		buf = append(buf, s.Bytes()...)
+		// previous fields may reference a previous backing array (which is left untouched)
+		fields = append(fields, buf[start:len(buf):len(buf)])
+		if s.EndOfRecord() {
+			return buf, fields, nil
+		}
+	}
+	if len(fields) == 0 {
+		fields = nil
 	}
+	return buf, fields, s.Err()
 }
 
 // ScanField implements bufio.SplitFunc for CSV.
 // Lexing is adapted from csv_read_one_field function in SQLite3 shell sources.
 func (s *Reader) ScanField(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if s.guess {
+		s.guess = false
+		var q byte
+		if s.quoted || s.GuessQuoted {
+			q = s.quoteChar()
+		}
+		if b := guess(data, q); b > 0 {
+			s.sep = b
+		}
+	}
+	if s.GuessQuoted && !s.probed && s.stream == streamOff {
+		s.probed = true
+		if quoted, ok := guessQuoted(data, s.sep, s.quoteChar()); ok {
+			s.quoted = quoted
+		}
+	}
+	field := s.stream == streamOff // whole field (not streamed or skipped)
+	first, line, offset := s.eor, s.lineno, s.offset
+	if first && field {
+		s.recBytes = 0
+	}
+	advance, token, err = s.scan(data, atEOF)
+	if err != nil {
+		return
+	}
+	if s.MaxFieldSize > 0 && field && (len(token) > s.MaxFieldSize || token == nil && advance == 0 && len(data) > s.MaxFieldSize) {
+		if !s.TruncateFields || token == nil && (s.SepRegexp != nil || s.SepString != "" || s.widths != nil || s.Escape != 0) {
+			return 0, nil, s.parseError(first, line, offset, ErrFieldTooLarge)
+		}
+		if s.OnTruncate != nil {
+			e := s.parseError(first, line, offset, nil)
+			s.OnTruncate(e.Record, e.Column)
+		}
+		if token != nil {
+			token = token[:s.MaxFieldSize]
+		} else { // stream the rest of the field
+			s.truncating = true
+			s.stream = streamStart
+			s.trunc = s.trunc[:0]
+			if advance, token, err = s.truncate(data, atEOF); err != nil {
+				return
+			}
+		}
+	}
+	s.offset += int64(advance)
+	if s.MaxRecordBytes > 0 {
+		s.recBytes += int64(advance)
+		if s.recBytes > s.MaxRecordBytes || token == nil && s.recBytes+int64(len(data)) > s.MaxRecordBytes {
+			return 0, nil, s.parseError(first, line, offset, ErrRecordTooLarge)
+		}
+	}
+	if !field || token == nil {
+		return
+	}
+	if first && s.MaxRecordsTotal > 0 && (!s.eor || len(token) > 0) { // empty lines are not counted
+		if s.total++; s.total > s.MaxRecordsTotal {
+			return 0, nil, s.parseError(first, line, offset, ErrTooManyRecords)
+		}
+	}
+	if len(token) == 0 {
+		return
+	}
+	if s.SmartQuotes {
+		token = normalizeQuotes(token)
+	}
+	if s.ValidateUTF8 && !utf8.Valid(token) {
+		return 0, nil, s.parseError(first, line, offset, ErrInvalidUTF8)
+	}
+	return
+}
+
+// parseError reports err for the field being scanned.
+func (s *Reader) parseError(first bool, line int, offset int64, err error) *ParseError {
+	e := &ParseError{Line: line, Record: s.recno, Column: s.col + 2, Offset: offset, Err: err}
+	if first {
+		e.Record++
+		e.Column = 1
+	}
+	return e
+}
+
+// scan dispatches to the split path matching the current mode.
+func (s *Reader) scan(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if s.stream != streamOff || s.SepRegexp != nil || s.SepString != "" || s.widths != nil {
+		s.nl = 0 // offset not maintained by other paths
+	}
+	if s.truncating {
+		return s.truncate(data, atEOF)
+	} else if s.SepRegexp != nil && s.stream == streamOff {
+		return s.scanRegexpField(data, atEOF)
+	} else if s.SepString != "" && s.stream == streamOff {
+		return s.scanStringSepField(data, atEOF)
+	} else if s.widths != nil && s.stream == streamOff {
+		return s.scanFixedField(data, atEOF)
+	} else if s.stream >= skipStart {
+		return s.skipRest(data, atEOF)
+	} else if s.stream != streamOff {
+		return s.scanChunk(data, atEOF)
+	}
 	var a int
 	for {
 		a, token, err = s.scanField(data, atEOF)
@@ -265,59 +1140,91 @@ func (s *Reader) ScanField(data []byte, atEOF bool) (advance int, token []byte,
 }
 
 func (s *Reader) scanField(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	nl := s.nl - 1 // offset of the newline found by the previous call (unless -1)
+	s.nl = 0
 	if atEOF && len(data) == 0 && s.eor {
 		return 0, nil, nil
 	}
-	if s.guess {
-		s.guess = false
-		if b := guess(data); b > 0 {
-			s.sep = b
-		}
-	}
-	if s.quoted && len(data) > 0 && data[0] == '"' { // quoted field (may contains separator, newline and escaped quote)
-		startLineno := s.lineno
+	q := s.quoteChar()
+	if s.quoted && len(data) > 0 && data[0] == q && !s.literal && s.Escape != 0 { // quoted field with escape character
+		return s.scanEscapedQuotedField(data, atEOF, q)
+	} else if s.quoted && len(data) > 0 && data[0] == q && !s.literal { // quoted field (may contains separator, newline and escaped quote)
 		escapedQuotes := 0
 		strict := true
-		var c, pc, ppc byte
-		// Scan until the separator or newline following the closing quote (and ignore escaped quote)
-		for i := 1; i < len(data); i++ {
-			c = data[i]
-			if c == '\n' {
-				s.lineno++
-			} else if c == '"' {
-				if pc == c { // escaped quote
-					pc = 0
-					escapedQuotes++
-					continue
+		lines, counted := 0, 1 // number of newlines in data[1:counted] (see MaxQuotedLines)
+		tooLong := func(end int) bool {
+			if s.MaxQuotedLines <= 0 {
+				return false
+			}
+			lines += bytes.Count(data[counted:end], newline)
+			counted = end
+			return lines >= s.MaxQuotedLines
+		}
+		// Jump from quote to quote until the separator or newline following the closing quote (and skip escaped quotes)
+		for i := 1; ; {
+			j := bytes.IndexByte(data[i:], q)
+			if j < 0 {
+				break
+			}
+			i += j
+			if tooLong(i) {
+				return s.scanLiteral(data, atEOF)
+			}
+			if i+1 == len(data) { // may be followed by an escaped quote, a separator or a newline
+				if !atEOF {
+					break
 				}
+				s.lineno += bytes.Count(data[1:i], newline)
+				s.eor = true
+				return len(data), s.quotedValue(data[1:i], q, escapedQuotes, strict), nil
 			}
-			if pc == '"' && c == s.sep {
+			switch data[i+1] {
+			case q: // escaped quote
+				escapedQuotes++
+				i += 2
+				continue
+			case s.sep:
+				s.lineno += bytes.Count(data[1:i], newline)
 				s.eor = false
-				return i + 1, unescapeQuotes(data[1:i-1], escapedQuotes, strict), nil
-			} else if pc == '"' && c == '\n' {
-				s.eor = true
-				return i + 1, unescapeQuotes(data[1:i-1], escapedQuotes, strict), nil
-			} else if c == '\n' && pc == '\r' && ppc == '"' {
+				return i + 2, s.quotedValue(data[1:i], q, escapedQuotes, strict), nil
+			case '\n':
+				s.lineno += bytes.Count(data[1:i], newline) + 1
+				s.lf++
 				s.eor = true
-				return i + 1, unescapeQuotes(data[1:i-2], escapedQuotes, strict), nil
+				return i + 2, s.quotedValue(data[1:i], q, escapedQuotes, strict), nil
+			case '\r':
+				if i+2 < len(data) && data[i+2] == '\n' {
+					s.lineno += bytes.Count(data[1:i], newline) + 1
+					s.crlf++
+					s.eor = true
+					return i + 3, s.quotedValue(data[1:i], q, escapedQuotes, strict), nil
+				} else if i+2 == len(data) && atEOF {
+					s.lineno += bytes.Count(data[1:i], newline)
+					s.eor = true
+					return len(data), s.quotedValue(data[1:i], q, escapedQuotes, strict), nil
+				}
+			}
+			if i+2 == len(data) && data[i+1] == '\r' {
+				break // request more data (may be followed by '\n')
 			}
-			if pc == '"' && c != '\r' {
-				if s.Lazy {
-					strict = false
-				} else {
-					return 0, nil, fmt.Errorf("unescaped %c character at line %d", pc, s.lineno)
+			if !s.Lazy {
+				if s.OnResync != nil {
+					return s.resync(data, atEOF)
 				}
+				return 0, nil, fmt.Errorf("unescaped %c character at line %d", q, s.lineno+bytes.Count(data[1:i], newline))
 			}
-			ppc = pc
-			pc = c
+			strict = false
+			i++
+		}
+		if tooLong(len(data)) {
+			return s.scanLiteral(data, atEOF)
 		}
 		if atEOF {
-			if c == '"' {
-				s.eor = true
-				return len(data), unescapeQuotes(data[1:len(data)-1], escapedQuotes, strict), nil
-			}
 			// If we're at EOF, we have a non-terminated field.
-			return 0, nil, fmt.Errorf("non-terminated quoted field between lines %d and %d", startLineno, s.lineno)
+			if s.OnResync != nil {
+				return s.resync(data, atEOF)
+			}
+			return 0, nil, fmt.Errorf("non-terminated quoted field between lines %d and %d", s.lineno, s.lineno+bytes.Count(data, newline))
 		}
 	} else if s.eor && s.Comment != 0 && len(data) > 0 && data[0] == s.Comment { // line comment
 		for i, c := range data {
@@ -329,30 +1236,40 @@ func (s *Reader) scanField(data []byte, atEOF bool) (advance int, token []byte,
 		if atEOF {
 			return len(data), nil, nil
 		}
+	} else if s.Escape != 0 { // unquoted field with escape character
+		return s.scanEscapedField(data, atEOF)
 	} else { // unquoted field
 		// Scan until separator or newline, marking end of field.
-		for i, c := range data {
-			if c == s.sep {
-				s.eor = false
-				if s.Trim {
-					return i + 1, trim(data[0:i]), nil
-				}
-				return i + 1, data[0:i], nil
-			} else if c == '\n' {
-				s.lineno++
-				if i > 0 && data[i-1] == '\r' {
-					s.eor = true
-					if s.Trim {
-						return i + 1, trim(data[0 : i-1]), nil
-					}
-					return i + 1, data[0 : i-1], nil
-				}
-				s.eor = true
-				if s.Trim {
-					return i + 1, trim(data[0:i]), nil
-				}
-				return i + 1, data[0:i], nil
+		// The newline is searched first (and its offset kept for the following fields of the same line).
+		if nl < 0 || nl >= len(data) || data[nl] != '\n' {
+			nl = bytes.IndexByte(data, '\n')
+		}
+		line := data
+		if nl >= 0 {
+			line = data[:nl]
+		}
+		if i := bytes.IndexByte(line, s.sep); i >= 0 {
+			s.eor = false
+			if nl >= 0 {
+				s.nl = nl - i // offset from the next field + 1
+			}
+			if s.Trim {
+				return i + 1, trim(data[0:i]), nil
+			}
+			return i + 1, data[0:i], nil
+		} else if nl >= 0 {
+			s.lineno++
+			s.eor = true
+			if nl > 0 && data[nl-1] == '\r' {
+				line = data[:nl-1]
+				s.crlf++
+			} else {
+				s.lf++
+			}
+			if s.Trim {
+				return nl + 1, trim(line), nil
 			}
+			return nl + 1, line, nil
 		}
 		// If we're at EOF, we have a final field. Return it.
 		if atEOF {
@@ -367,38 +1284,371 @@ func (s *Reader) scanField(data []byte, atEOF bool) (advance int, token []byte,
 	return 0, nil, nil
 }
 
-func unescapeQuotes(b []byte, count int, strict bool) []byte {
+// scanLiteral scans the field starting at data[0] as an unquoted one (see MaxQuotedLines).
+func (s *Reader) scanLiteral(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	s.literal = true
+	advance, token, err = s.scanField(data, atEOF)
+	s.literal = false
+	return
+}
+
+// quoteChar returns the character enclosing quoted values.
+func (s *Reader) quoteChar() byte {
+	if s.Quote == 0 {
+		return '"'
+	}
+	return s.Quote
+}
+
+// normalizeQuotes replaces (in place) typographic quotes (“ ” „) by plain double quotes.
+func normalizeQuotes(b []byte) []byte {
+	i := bytes.Index(b, []byte{0xe2, 0x80})
+	if i < 0 {
+		return b
+	}
+	j := i
+	for ; i < len(b); i, j = i+1, j+1 {
+		if b[i] == 0xe2 && i+2 < len(b) && b[i+1] == 0x80 && b[i+2] >= 0x9c && b[i+2] <= 0x9e { // U+201C, U+201D, U+201E
+			b[j] = '"'
+			i += 2
+			continue
+		}
+		b[j] = b[i]
+	}
+	return b[:j]
+}
+
+// Newlines tells how line breaks inside quoted values are returned.
+type Newlines int
+
+// Line breaks modes
+const (
+	NewlinesKeep  Newlines = iota // line breaks are kept as is
+	NewlinesLF                    // \r\n and lone \r are converted to \n
+	NewlinesStrip                 // \r\n, \r and \n are removed
+)
+
+// quotedValue unescapes quotes and normalizes line breaks (in place) of a quoted value.
+func (s *Reader) quotedValue(b []byte, q byte, escapedQuotes int, strict bool) []byte {
+	b = unescapeQuotes(b, q, escapedQuotes, strict)
+	switch s.QuotedNewlines {
+	case NewlinesLF:
+		if bytes.IndexByte(b, '\r') < 0 {
+			return b
+		}
+		j := 0
+		for i := 0; i < len(b); i++ {
+			if b[i] == '\r' {
+				if i+1 < len(b) && b[i+1] == '\n' {
+					continue
+				}
+				b[j] = '\n'
+			} else {
+				b[j] = b[i]
+			}
+			j++
+		}
+		return b[:j]
+	case NewlinesStrip:
+		if bytes.IndexAny(b, "\r\n") < 0 {
+			return b
+		}
+		j := 0
+		for _, c := range b {
+			if c != '\r' && c != '\n' {
+				b[j] = c
+				j++
+			}
+		}
+		return b[:j]
+	}
+	return b
+}
+
+// newline is searched to count lines.
+var newline = []byte{'\n'}
+
+// scanEscapedField scans an unquoted field where the separator, a newline or the escape character itself
+// may be preceded by the escape character.
+func (s *Reader) scanEscapedField(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	escapes, newlines := 0, 0
+	escaped := -1 // index of the last escaped character
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if c == s.Escape {
+			if i+1 == len(data) {
+				break // request more data
+			}
+			i++
+			escapes++
+			escaped = i
+			if data[i] == '\n' {
+				newlines++
+			}
+			continue
+		} else if c != s.sep && c != '\n' {
+			continue
+		}
+		end := i
+		s.eor = c == '\n'
+		if s.eor {
+			newlines++
+			if i > 0 && data[i-1] == '\r' && escaped != i-1 {
+				end--
+				s.crlf++
+			} else {
+				s.lf++
+			}
+		}
+		s.lineno += newlines
+		return i + 1, s.trimField(unescape(data[:end], s.Escape, escapes)), nil
+	}
+	if atEOF {
+		s.lineno += newlines
+		s.eor = true
+		return len(data), s.trimField(unescape(data, s.Escape, escapes)), nil
+	}
+	return 0, nil, nil
+}
+
+// scanEscapedQuotedField scans a quoted field where a quote (or any character) may be preceded by the escape character
+// (\n and \r standing for a newline and a carriage return, like Writer.Escape output). Doubled quotes are also accepted.
+func (s *Reader) scanEscapedQuotedField(data []byte, atEOF bool, q byte) (advance int, token []byte, err error) {
+	escapes := 0
+	for i := 1; i < len(data); i++ {
+		c := data[i]
+		if c == s.Escape {
+			if i+1 == len(data) {
+				break // request more data
+			}
+			i++
+			escapes++
+			continue
+		} else if c != q {
+			continue
+		}
+		if i+1 == len(data) {
+			if !atEOF {
+				break // may be followed by a doubled quote, a separator or a newline
+			}
+			s.lineno += bytes.Count(data[1:i], newline)
+			s.eor = true
+			return len(data), s.escapedValue(data[1:i], q, escapes), nil
+		}
+		end := i + 2
+		switch data[i+1] {
+		case q: // doubled quote
+			i++
+			escapes++
+			continue
+		case s.sep:
+			s.eor = false
+		case '\n':
+			s.eor = true
+			s.lf++
+		case '\r':
+			if i+2 == len(data) && !atEOF {
+				return 0, nil, nil // request more data (may be followed by '\n')
+			} else if i+2 == len(data) {
+				end = len(data)
+			} else if data[i+2] != '\n' {
+				return 0, nil, fmt.Errorf("unescaped %c character at line %d", q, s.lineno+bytes.Count(data[1:i], newline))
+			} else {
+				end++
+				s.crlf++
+			}
+			s.eor = true
+		default:
+			return 0, nil, fmt.Errorf("unescaped %c character at line %d", q, s.lineno+bytes.Count(data[1:i], newline))
+		}
+		s.lineno += bytes.Count(data[1:end], newline)
+		return end, s.escapedValue(data[1:i], q, escapes), nil
+	}
+	if atEOF {
+		return 0, nil, fmt.Errorf("non-terminated quoted field between lines %d and %d", s.lineno, s.lineno+bytes.Count(data, newline))
+	}
+	return 0, nil, nil
+}
+
+// escapedValue removes (in place) the count escape characters (or doubled quotes) from b.
+func (s *Reader) escapedValue(b []byte, q byte, count int) []byte {
+	if count > 0 {
+		j := 0
+		for i := 0; i < len(b); i, j = i+1, j+1 {
+			c := b[i]
+			if escaped := c == s.Escape; (escaped || c == q) && i+1 < len(b) {
+				i++
+				if c = b[i]; escaped && c == 'n' {
+					c = '\n'
+				} else if escaped && c == 'r' {
+					c = '\r'
+				}
+			}
+			b[j] = c
+		}
+		b = b[:j]
+	}
+	return s.quotedValue(b, q, 0, true)
+}
+
+// unescape removes (in place) the count escape characters from b.
+func unescape(b []byte, esc byte, count int) []byte {
+	if count == 0 {
+		return b
+	}
+	j := 0
+	for i := 0; i < len(b); i, j = i+1, j+1 {
+		if b[i] == esc && i+1 < len(b) {
+			i++
+		}
+		b[j] = b[i]
+	}
+	return b[:j]
+}
+
+func unescapeQuotes(b []byte, q byte, count int, strict bool) []byte {
 	if count == 0 {
 		return b
 	}
 	for i, j := 0, 0; i < len(b); i, j = i+1, j+1 {
 		b[j] = b[i]
-		if b[i] == '"' && (strict || i < len(b)-1 && b[i+1] == '"') {
+		if b[i] == q && (strict || i < len(b)-1 && b[i+1] == q) {
 			i++
 		}
 	}
 	return b[:len(b)-count]
 }
 
-func guess(data []byte) byte {
-	seps := []byte{',', ';', '\t', '|', ':'}
-	count := make(map[byte]uint)
+// guessSeps are the separator candidates (by order of preference when tied).
+var guessSeps = []byte{',', ';', '\t', '|', ':'}
+
+// guessLines is the number of lines used to verify the guessed separator.
+const guessLines = 10
+
+// guess returns the separator candidate producing the most consistent number of fields
+// on the first complete (non empty) lines of data:
+// the candidate occurring the same (non zero) number of times on most lines wins,
+// ties being broken by the number of occurrences by line then by order of preference.
+// When no candidate is consistent on more than half of the lines (or when there is no complete line),
+// the most frequent one is returned.
+// When quote is specified (not 0), separators occurring inside quoted fields are not counted.
+func guess(data []byte, quote byte) byte {
+	var total [5]uint
+	var lines [][5]uint // counts by complete line
+	var line [5]uint
+	empty := true
+	inQuotes := false
 	for _, b := range data {
-		if bytes.IndexByte(seps, b) >= 0 {
-			count[b]++
-			/*} else if b == '\n' {
-			break*/
+		if quote != 0 && b == quote { // an escaped quote toggles twice
+			inQuotes = !inQuotes
+		} else if inQuotes {
+			continue
+		} else if b == '\n' {
+			if !empty {
+				lines = append(lines, line)
+				if len(lines) == guessLines {
+					break
+				}
+			}
+			line = [5]uint{}
+			empty = true
+			continue
+		} else if i := bytes.IndexByte(guessSeps, b); i >= 0 {
+			total[i]++
+			line[i]++
+		}
+		if b != '\r' {
+			empty = false
+		}
+	}
+	best, bestMode, bestAgree := -1, uint(0), 0
+	for i := range guessSeps {
+		mode, agree := guessMode(lines, i)
+		if mode > 0 && (agree > bestAgree || agree == bestAgree && mode > bestMode) {
+			best, bestMode, bestAgree = i, mode, agree
 		}
 	}
-	var max uint
-	var sep byte
-	for b, c := range count {
-		if c > max {
-			max = c
-			sep = b
+	if best >= 0 && bestAgree*2 > len(lines) {
+		return guessSeps[best]
+	}
+	// no consistent candidate
+	best = -1
+	for i, c := range total {
+		if c > 0 && (best < 0 || c > total[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return 0
+	}
+	return guessSeps[best]
+}
+
+// guessMode returns the most common non zero number of occurrences (the greatest when tied) of the i-th candidate by line
+// and the number of lines having it.
+func guessMode(lines [][5]uint, i int) (mode uint, agree int) {
+	for _, l := range lines {
+		c := l[i]
+		if c == 0 || c == mode {
+			continue
+		}
+		n := 0
+		for _, o := range lines {
+			if o[i] == c {
+				n++
+			}
+		}
+		if n > agree || n == agree && c > mode {
+			mode, agree = c, n
+		}
+	}
+	return mode, agree
+}
+
+// guessQuoted tells if data uses rfc4180 quoting:
+// quoted fields must be followed by a separator or a newline and no unquoted field may contain a quote.
+// ok is false when data contains no quote.
+func guessQuoted(data []byte, sep, quote byte) (quoted bool, ok bool) {
+	var good, bad int
+	for i := 0; i < len(data); {
+		if data[i] == quote { // quoted field
+			j := i + 1
+			for j < len(data) {
+				if data[j] == quote {
+					if j+1 < len(data) && data[j+1] == quote { // escaped quote
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j >= len(data) { // truncated sample
+				break
+			}
+			j++
+			if j == len(data) || data[j] == sep || data[j] == '\n' || data[j] == '\r' {
+				good++
+			} else {
+				bad++
+			}
+			i = j
+		} else { // unquoted field
+			for i < len(data) && data[i] != sep && data[i] != '\n' {
+				if data[i] == quote {
+					bad++
+				}
+				i++
+			}
+		}
+		if i < len(data) { // skip separator or newline
+			i++
 		}
 	}
-	return sep
+	if good == 0 && bad == 0 {
+		return false, false
+	}
+	return bad == 0, true
 }
 
 // bytes.TrimSpace may return nil...
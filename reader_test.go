@@ -5,7 +5,11 @@
 package yacr_test
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
@@ -33,6 +37,31 @@ func TestLongLine(t *testing.T) {
 	}
 }
 
+func TestHugeField(t *testing.T) {
+	huge := strings.Repeat("abc\n", 1<<18)
+	for _, quoted := range []bool{false, true} {
+		content := "1," + strconv.Quote(huge) + "," + strings.Repeat("x", 1<<20) + "\n2"
+		if quoted {
+			content = strings.Replace(content, `\n`, "\n", -1)
+		}
+		r := NewReader(strings.NewReader(content), ',', quoted, false)
+		var lengths []int
+		for r.Scan() {
+			lengths = append(lengths, len(r.Bytes()))
+		}
+		if err := r.Err(); err != nil {
+			t.Fatal(err)
+		}
+		want := []int{1, len(huge), 1 << 20, 1}
+		if !quoted {
+			want[1] = len(strconv.Quote(huge))
+		}
+		if !reflect.DeepEqual(lengths, want) {
+			t.Errorf("got %v; want %v", lengths, want)
+		}
+	}
+}
+
 // Stolen/adapted from $GOROOT/src/pkg/encoding/csv/reader_test.go
 var readTests = []struct {
 	Name   string
@@ -292,6 +321,25 @@ x,,,
 		Input:  "a,b;c\td:e|f;g",
 		Output: [][]string{{"a,b", "c\td:e|f", "g"}},
 	},
+	{
+		Name:   "GuessQuoted",
+		Guess:  ';',
+		Quoted: true,
+		Input:  `"Doe, John, Jr";"a,b";1`,
+		Output: [][]string{{"Doe, John, Jr", "a,b", "1"}},
+	},
+	{
+		Name:   "GuessFallback",
+		Guess:  ';',
+		Input:  "id;desc\n1;a, b, c, d\n2;e\n",
+		Output: [][]string{{"id", "desc"}, {"1", "a, b, c, d"}, {"2", "e"}},
+	},
+	{
+		Name:   "GuessRagged",
+		Guess:  ';',
+		Input:  "id;desc\n1;a, b, c, d\n2;e, f, g\n3;h;i\n",
+		Output: [][]string{{"id", "desc"}, {"1", "a, b, c, d"}, {"2", "e, f, g"}, {"3", "h", "i"}},
+	},
 	{
 		Name:   "6287",
 		Input:  `Field1,Field2,"LazyQuotes" Field3,Field4,Field5`,
@@ -440,6 +488,896 @@ func TestScanTypedRecord(t *testing.T) {
 	}
 }
 
+func TestScanTime(t *testing.T) {
+	r := DefaultReader(strings.NewReader("2024-03-01,2024-03-01 12:30:00,2024-03-01T12:30:00.5+01:00\n01/03/2024\nyesterday\n"))
+	var d1, d2, d3 time.Time
+	if _, err := r.ScanRecord(&d1, &d2, &d3); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC); !d1.Equal(want) {
+		t.Errorf("got %v; want %v", d1, want)
+	} else if want = time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC); !d2.Equal(want) {
+		t.Errorf("got %v; want %v", d2, want)
+	} else if want = time.Date(2024, 3, 1, 11, 30, 0, 5e8, time.UTC); !d3.Equal(want) {
+		t.Errorf("got %v; want %v", d3, want)
+	}
+	r.TimeLayouts = []string{"02/01/2006"}
+	if _, err := r.ScanRecord(&d1); err != nil {
+		t.Fatal(err)
+	} else if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC); !d1.Equal(want) {
+		t.Errorf("got %v; want %v", d1, want)
+	}
+	var fe *FieldError
+	if _, err := r.ScanRecord(&d1); !errors.As(err, &fe) || fe.Text != "yesterday" || !strings.Contains(err.Error(), "02/01/2006") {
+		t.Errorf("got %v; want a *FieldError listing the layouts", err)
+	}
+}
+
+func TestGuessNext(t *testing.T) {
+	r := NewReader(strings.NewReader("a;b;c\nd;e;f\n"+"g|h\ni|j\n"), ',', true, true)
+	var records [][]string
+	for i := 0; ; i++ {
+		if i == 2 {
+			r.GuessNext()
+		}
+		record, err := r.Record()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if want := [][]string{{"a", "b", "c"}, {"d", "e", "f"}, {"g", "h"}, {"i", "j"}}; !reflect.DeepEqual(records, want) {
+		t.Errorf("got %q; want %q", records, want)
+	}
+	if r.Sep() != '|' {
+		t.Errorf("got '%c'; want '%c'", r.Sep(), '|')
+	}
+}
+
+func TestSingleQuote(t *testing.T) {
+	r := NewReader(strings.NewReader("'a;b';'it''s'\n'c\nd';\"e\"\n"), ';', true, false)
+	r.Quote = '\''
+	var records [][]string
+	for {
+		record, err := r.Record()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if want := [][]string{{"a;b", "it's"}, {"c\nd", `"e"`}}; !reflect.DeepEqual(records, want) {
+		t.Errorf("got %q; want %q", records, want)
+	}
+}
+
+func TestSmartQuotes(t *testing.T) {
+	r := NewReader(strings.NewReader("3376027\t”S” Falls\t„a“\t\"b\"\n"), '\t', false, false)
+	r.SmartQuotes = true
+	record, err := r.Record()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"3376027", `"S" Falls`, `"a"`, `"b"`}; !reflect.DeepEqual(record, want) {
+		t.Errorf("got %q; want %q", record, want)
+	}
+}
+
+func TestGuessQuoted(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Sep    byte
+		Quoted bool
+		Input  string
+		Output []string
+	}{
+		{"Quoted", ',', false, "\"a,b\",\"c\"\"d\"\n", []string{"a,b", `c"d`}},
+		{"Bare", '\t', true, "3376027\t\"S\" Falls\t4.53333\n", []string{"3376027", `"S" Falls`, "4.53333"}},
+		{"NoQuote", ',', true, "a,b\n", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		r := NewReader(strings.NewReader(tt.Input), tt.Sep, tt.Quoted, false)
+		r.GuessQuoted = true
+		record, err := r.Record()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.Name, err)
+		} else if !reflect.DeepEqual(record, tt.Output) {
+			t.Errorf("%s: got %q; want %q", tt.Name, record, tt.Output)
+		}
+	}
+}
+
+func TestSepRegexp(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a  b\tc\r\n\nd, e ,f\ng"))
+	r.SepRegexp = regexp.MustCompile(`\s*[,\t]\s*| {2,}`)
+	var records [][]string
+	for {
+		record, err := r.Record()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if want := [][]string{{"a", "b", "c"}, {"d", "e", "f"}, {"g"}}; !reflect.DeepEqual(records, want) {
+		t.Errorf("got %q; want %q", records, want)
+	}
+	if r.LineNumber() != 4 {
+		t.Errorf("got line %d; want %d", r.LineNumber(), 4)
+	}
+}
+
+func TestScanRecordPartial(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,1,x\nb,2\nc\n\nd\n"))
+	var str, opt string
+	var i int
+	var n int
+	var err error
+	var got []string
+	for {
+		if n, err = r.ScanRecordPartial(1, &str, &i, &opt); err != nil || n == 0 {
+			break
+		}
+		got = append(got, str+":"+strconv.Itoa(i)+":"+opt)
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"a:1:x", "b:2:", "c:0:", "d:0:"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	r = DefaultReader(strings.NewReader("a,1,x\nb\n"))
+	if _, err = r.ScanRecordPartial(2, &str, &i, &opt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, err = r.ScanRecordPartial(2, &str, &i, &opt); err == nil || n != 1 {
+		t.Errorf("got (%d, %v); want (1, error)", n, err)
+	} else if !errors.Is(err, ErrTooFewFields) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestScanRecordStrict(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,b\n\nc\nd,e,f\ng,h\n"))
+	r.Strict = true
+	var x, y string
+	var errs []error
+	for {
+		n, err := r.ScanRecord(&x, &y)
+		if err != nil {
+			errs = append(errs, err)
+		} else if n == 0 {
+			break
+		}
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %v; want 2 errors", errs)
+	}
+	var ae *ArityError
+	if !errors.Is(errs[0], ErrTooFewFields) || !errors.As(errs[0], &ae) || ae.Record != 2 || ae.Got != 1 || ae.Want != 2 {
+		t.Errorf("unexpected error: %#v", errs[0])
+	}
+	if !errors.Is(errs[1], ErrTooManyFields) || !errors.As(errs[1], &ae) || ae.Record != 3 || ae.Got != 3 {
+		t.Errorf("unexpected error: %#v", errs[1])
+	}
+	if r.RecordNumber() != 4 {
+		t.Errorf("got record %d; want %d", r.RecordNumber(), 4)
+	}
+}
+
+func TestBoolTokens(t *testing.T) {
+	r := DefaultReader(strings.NewReader("Y,n,1\nyes,x,0\n"))
+	r.Bools = &BoolTokens{True: []string{"y", "yes", "1"}, False: []string{"n", "no", "0"}}
+	var a, b, c bool
+	if _, err := r.ScanRecord(&a, &b, &c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !a || b || !c {
+		t.Errorf("got %t, %t, %t; want true, false, true", a, b, c)
+	}
+	if _, err := r.ScanRecord(&a, &b, &c); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("got %v; want syntax error", err)
+	}
+}
+
+func TestInterner(t *testing.T) {
+	content := strings.Repeat("FR,a\nUS,b\n", 100)
+	r := DefaultReader(strings.NewReader(content))
+	r.Interner = NewInterner(3)
+	var records [][]string
+	for {
+		record, err := r.Record()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 200 || records[198][0] != "FR" || records[199][1] != "b" {
+		t.Errorf("unexpected records: %q", records[len(records)-2:])
+	}
+	if n := r.Interner.Len(); n != 3 {
+		t.Errorf("got %d interned strings; want %d", n, 3)
+	}
+	in := NewInterner(0)
+	buf := []byte("FR")
+	in.Intern(buf)
+	if n := testing.AllocsPerRun(100, func() { in.Intern(buf) }); n != 0 {
+		t.Errorf("got %f allocs; want 0", n)
+	}
+}
+
+func TestTextCache(t *testing.T) {
+	r := DefaultReader(strings.NewReader("abc,def\n"))
+	if !r.Scan() {
+		t.Fatal(r.Err())
+	}
+	r.Text()
+	if n := testing.AllocsPerRun(100, func() { r.Text() }); n != 0 {
+		t.Errorf("got %f allocs; want 0", n)
+	}
+	if !r.Scan() {
+		t.Fatal(r.Err())
+	}
+	if text := r.Text(); text != "def" {
+		t.Errorf("got %q; want %q", text, "def")
+	}
+}
+
+func TestWideRecords(t *testing.T) {
+	var want [][]string
+	var b strings.Builder
+	for i := 1; i < 50; i++ {
+		var record []string
+		for j := 0; j < i; j++ {
+			record = append(record, strconv.Itoa(i*j))
+		}
+		want = append(want, record)
+		b.WriteString(strings.Join(record, ","))
+		if i%2 == 0 {
+			b.WriteString("\r")
+		}
+		b.WriteString("\n")
+	}
+	r := DefaultReader(strings.NewReader(b.String()))
+	r.Buffer(make([]byte, 7), 1024) // many refills
+	var got [][]string
+	for {
+		record, err := r.Record()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, record)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestQuotedRefill(t *testing.T) {
+	input := "\"a\nb\nc\",\"d\"\"e\"\r\n\"f\"\"\"\"\",g\r\n\"h\ni\"\r"
+	want := [][]string{{"a\nb\nc", `d"e`}, {`f""`, "g"}, {"h\ni"}}
+	for _, size := range []int{2, 3, 5, 64} {
+		r := DefaultReader(strings.NewReader(input))
+		r.Buffer(make([]byte, size), 1024)
+		var got [][]string
+		for {
+			record, err := r.Record()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%d: %v", size, err)
+			}
+			got = append(got, record)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got %q; want %q", size, got, want)
+		}
+		if r.LineNumber() != 6 {
+			t.Errorf("%d: got line %d; want %d", size, r.LineNumber(), 6)
+		}
+	}
+}
+
+type celsius float32
+type code uint8
+type label string
+
+func TestScanReflect(t *testing.T) {
+	r := DefaultReader(strings.NewReader("21.5,200,x\n-3,7,y\n"))
+	var c celsius
+	var n code
+	var l label
+	var got []string
+	for {
+		if k, err := r.ScanRecord(&c, &n, &l); err != nil {
+			t.Fatal(err)
+		} else if k == 0 {
+			break
+		}
+		got = append(got, strconv.FormatFloat(float64(c), 'f', -1, 32)+":"+strconv.Itoa(int(n))+":"+string(l))
+	}
+	if want := []string{"21.5:200:x", "-3:7:y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	r = DefaultReader(strings.NewReader("256\n"))
+	if _, err := r.ScanRecord(&n); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("got %v; want range error", err)
+	}
+	var unsupported struct{}
+	r = DefaultReader(strings.NewReader("a\n"))
+	if _, err := r.ScanRecord(&unsupported); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestScanNumberAllocs(t *testing.T) {
+	r := DefaultReader(strings.NewReader(strings.Repeat("123,-4.5,true,7\n", 200)))
+	var i int
+	var f float64
+	var b bool
+	var u uint16
+	values := []interface{}{&i, &f, &b, &u}
+	if n := testing.AllocsPerRun(100, func() { r.ScanRecord(values...) }); n != 0 {
+		t.Errorf("got %f allocs per record; want 0", n)
+	}
+	if i != 123 || f != -4.5 || !b || u != 7 {
+		t.Errorf("unexpected values: %d, %f, %t, %d", i, f, b, u)
+	}
+}
+
+func TestQuotedNewlines(t *testing.T) {
+	input := "\"a\r\nb\rc\nd\",e\r\n"
+	tests := []struct {
+		Mode   Newlines
+		Output []string
+	}{
+		{NewlinesKeep, []string{"a\r\nb\rc\nd", "e"}},
+		{NewlinesLF, []string{"a\nb\nc\nd", "e"}},
+		{NewlinesStrip, []string{"abcd", "e"}},
+	}
+	for _, tt := range tests {
+		r := DefaultReader(strings.NewReader(input))
+		r.QuotedNewlines = tt.Mode
+		record, err := r.Record()
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", tt.Mode, err)
+		} else if !reflect.DeepEqual(record, tt.Output) {
+			t.Errorf("%d: got %q; want %q", tt.Mode, record, tt.Output)
+		}
+	}
+}
+
+func TestValidateUTF8(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,b\nc,\"d\xffe\"\n"))
+	r.ValidateUTF8 = true
+	var err error
+	for err == nil {
+		_, err = r.Record()
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrInvalidUTF8) {
+		t.Fatalf("got %v; want *ParseError", err)
+	}
+	if pe.Line != 2 || pe.Record != 2 || pe.Column != 2 || pe.Offset != 6 {
+		t.Errorf("unexpected error: %+v", pe)
+	}
+}
+
+func TestRecordLimits(t *testing.T) {
+	var tests = []struct {
+		name     string
+		input    string
+		maxBytes int64
+		maxTotal int
+		want     error
+		records  int
+	}{
+		{"Bytes", "a,b\nccccc,ddddd\n", 8, 0, ErrRecordTooLarge, 1},
+		{"RunawayQuote", "a,b\n\"" + strings.Repeat("x\n", 10000), 1024, 0, ErrRecordTooLarge, 1},
+		{"BytesOk", "a,b\nc,d\n", 8, 0, nil, 2},
+		{"Total", "a\n\nb\nc\n", 0, 2, ErrTooManyRecords, 2},
+		{"TotalOk", "a\n\nb\n", 0, 2, nil, 2},
+	}
+	for _, test := range tests {
+		r := DefaultReader(strings.NewReader(test.input))
+		r.MaxRecordBytes = test.maxBytes
+		r.MaxRecordsTotal = test.maxTotal
+		records := 0
+		var err error
+		for {
+			var values []string
+			if values, err = r.Record(); err != nil || values == nil {
+				break
+			}
+			records++
+		}
+		if err == io.EOF {
+			err = nil
+		}
+		if test.want == nil && err != nil || !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v; want %v", test.name, err, test.want)
+		} else if test.want != nil {
+			if _, ok := err.(*ParseError); !ok {
+				t.Errorf("%s: got %#v; want *ParseError", test.name, err)
+			}
+		}
+		if records != test.records {
+			t.Errorf("%s: got %d records; want %d", test.name, records, test.records)
+		}
+	}
+}
+
+func TestCloneFor(t *testing.T) {
+	r := NewReader(strings.NewReader("a; b\n"), ',', true, true)
+	r.Trim = true
+	r.Interner = NewInterner(10)
+	r.Buffer(make([]byte, 0, 16), 32)
+	if values, err := r.Record(); err != nil || !reflect.DeepEqual(values, []string{"a", "b"}) {
+		t.Fatalf("got %q (%v)", values, err)
+	}
+	c := r.CloneFor(strings.NewReader("c , d,e\n" + strings.Repeat("x", 40) + "\n"))
+	if c.Sep() != ';' {
+		t.Errorf("got %q; want %q", c.Sep(), ';')
+	}
+	if values, err := c.Record(); err != nil || !reflect.DeepEqual(values, []string{"c , d,e"}) {
+		t.Errorf("got %q (%v)", values, err)
+	}
+	if c.Interner == r.Interner || c.Interner.Len() != 1 || r.Interner.Len() != 2 {
+		t.Error("interner must not be shared")
+	}
+	if _, err := c.Record(); err == nil {
+		t.Error("error expected (token too long)")
+	}
+	if c.LineNumber() != 2 || r.LineNumber() != 2 {
+		t.Errorf("got %d and %d; want 2", c.LineNumber(), r.LineNumber())
+	}
+}
+
+func TestOnRaggedRecord(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,b,c\n\n1,2,3\n4,5\n6,\"7\n\",8,9\n10,11,12"))
+	var got [][3]int
+	r.OnRaggedRecord = func(recordNum, n, want int) {
+		got = append(got, [3]int{recordNum, n, want})
+	}
+	n := 0
+	for {
+		if _, err := r.Record(); err != nil {
+			break
+		} else if n++; n == 3 {
+			if err = r.UnreadRecord(); err != nil { // replayed records are not checked twice
+				t.Fatal(err)
+			}
+			r.Record()
+		}
+	}
+	if want := [][3]int{{3, 2, 3}, {4, 4, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestResync(t *testing.T) {
+	var tests = []struct {
+		input   string
+		want    string
+		skipped [][2]int64
+	}{
+		{"a,b,c\n1,\"2,3\n4,5,6\n7,8,9\n", "a,b,c|1,|4,5,6|7,8,9", [][2]int64{{8, 13}}},
+		{"a,b\n1,\"x\"y\n2,z\n\n3,\"w\"\n", "a,b|1,|2,z|3,w", [][2]int64{{6, 11}}},
+		{"a,b\n1,\"x\n2\n3,4,5", "a,b|1,", [][2]int64{{6, 16}}},
+		{"a,b\n\"x,1\n\"y\"\"\",2\n", "a,b|y\",2", [][2]int64{{4, 9}}}, // the truncated record looks like an empty line
+	}
+	for _, test := range tests {
+		r := DefaultReader(strings.NewReader(test.input))
+		var skipped [][2]int64
+		r.OnResync = func(start, end int64) {
+			skipped = append(skipped, [2]int64{start, end})
+		}
+		var records []string
+		for {
+			values, err := r.Record()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%q: %v", test.input, err)
+				break
+			}
+			records = append(records, strings.Join(values, ","))
+		}
+		if got := strings.Join(records, "|"); got != test.want {
+			t.Errorf("%q: got %q; want %q", test.input, got, test.want)
+		}
+		if !reflect.DeepEqual(skipped, test.skipped) {
+			t.Errorf("%q: got %v; want %v", test.input, skipped, test.skipped)
+		}
+	}
+}
+
+func TestTruncateFields(t *testing.T) {
+	input := "abc," + strings.Repeat("x", 5000) + ",def\n\"" + strings.Repeat("y\"\"\n", 2000) + "\",z\nw,0123456789\n"
+	for _, bufSize := range []int{16, 64 * 1024} {
+		r := DefaultReader(strings.NewReader(input))
+		r.Buffer(make([]byte, 0, bufSize), 1<<20)
+		r.MaxFieldSize = 8
+		if _, err := r.Record(); !errors.Is(err, ErrFieldTooLarge) {
+			t.Errorf("%d: got %v; want %v", bufSize, err, ErrFieldTooLarge)
+		}
+
+		r = DefaultReader(strings.NewReader(input))
+		r.Buffer(make([]byte, 0, bufSize), 1<<20)
+		r.MaxFieldSize = 8
+		r.TruncateFields = true
+		var truncated [][2]int
+		r.OnTruncate = func(recordNum, column int) {
+			truncated = append(truncated, [2]int{recordNum, column})
+		}
+		var records []string
+		for {
+			values, err := r.Record()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%d: %v", bufSize, err)
+			}
+			records = append(records, strings.Join(values, ","))
+		}
+		if want := "abc,xxxxxxxx,def|y\"\ny\"\ny\",z|w,01234567"; strings.Join(records, "|") != want {
+			t.Errorf("%d: got %q; want %q", bufSize, strings.Join(records, "|"), want)
+		}
+		if want := [][2]int{{1, 2}, {2, 1}, {3, 2}}; !reflect.DeepEqual(truncated, want) {
+			t.Errorf("%d: got %v; want %v", bufSize, truncated, want)
+		}
+		if r.LineNumber() != 2004 {
+			t.Errorf("%d: got line %d; want 2004", bufSize, r.LineNumber())
+		}
+	}
+}
+
+func TestMaxQuotedLines(t *testing.T) {
+	var tests = []struct {
+		input string
+		max   int
+		want  string
+	}{
+		{"a,\"b\nc\nd\"\ne,f\n", 3, "a,b\nc\nd|e,f"},
+		{"a,\"b\nc\nd\"\ne,f\n", 2, "a,\"b|c|d\"|e,f"},
+		{"1,5\" screen\n2,7\" screen\n3,x\n", 2, "1,5\" screen|2,7\" screen|3,x"},
+		{"1,\"5 screen\n2,7 screen\n3,x\n", 2, "1,\"5 screen|2,7 screen|3,x"},
+		{"1,\"5\"\" screen\",x\n", 1, "1,5\" screen,x"},
+	}
+	for _, test := range tests {
+		r := DefaultReader(strings.NewReader(test.input))
+		r.Lazy = true
+		r.MaxQuotedLines = test.max
+		var records []string
+		for {
+			values, err := r.Record()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%q: %v", test.input, err)
+			}
+			records = append(records, strings.Join(values, ","))
+		}
+		if got := strings.Join(records, "|"); got != test.want {
+			t.Errorf("%q: got %q; want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestFieldError(t *testing.T) {
+	r := DefaultReader(strings.NewReader("name,price\nfoo,12.5\nbar,\"12,5\"\n"))
+	if err := r.ScanHeaders(); err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	var price float64
+	var err error
+	for err == nil {
+		_, err = r.ScanRecord(&name, &price)
+	}
+	var fe *FieldError
+	if !errors.As(err, &fe) {
+		t.Fatalf("got %v; want *FieldError", err)
+	}
+	if fe.Name != "price" || fe.Column != 2 || fe.Record != 3 || fe.Text != "12,5" || !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("unexpected error: %#v", fe)
+	}
+	if want := `column "price", record 3: invalid syntax for "12,5"`; err.Error() != want {
+		t.Errorf("got %q; want %q", err.Error(), want)
+	}
+
+	r = DefaultReader(strings.NewReader("1,x\n"))
+	var i, j int8
+	if _, err = r.ScanRecord(&i, &j); err == nil || err.Error() != `column 2, record 1: invalid syntax for "x"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	for _, tt := range readTests {
+		var sep byte = ','
+		if tt.Sep != 0 {
+			sep = tt.Sep
+		}
+		r := NewReader(strings.NewReader(tt.Input), sep, tt.Quoted, tt.Guess != 0)
+		r.Comment = tt.Comment
+		r.Trim = tt.Trim
+		r.Lazy = tt.Lazy
+
+		var records [][]string
+		var err error
+		for {
+			var record []string
+			if record, err = r.Record(); err != nil {
+				break
+			}
+			records = append(records, record)
+		}
+		if tt.Error != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.Error) {
+				t.Errorf("%s: error %v, want error %q", tt.Name, err, tt.Error)
+			}
+		} else if err != io.EOF {
+			t.Errorf("%s: unexpected error: %v", tt.Name, err)
+		} else if !reflect.DeepEqual(records, tt.Output) {
+			t.Errorf("%s: got %q; want %q", tt.Name, records, tt.Output)
+		}
+	}
+}
+
+func TestScanRow(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,b,c\n\nd,\"e\nf\"\ng\n"))
+	var row []string
+	var rows [][]string
+	var err error
+	for row, err = r.ScanRow(row); err == nil; row, err = r.ScanRow(row) {
+		rows = append(rows, append([]string(nil), row...))
+	}
+	if err != io.EOF || row != nil {
+		t.Errorf("got (%q, %v); want (nil, EOF)", row, err)
+	}
+	if want := [][]string{{"a", "b", "c"}, {"d", "e\nf"}, {"g"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %q; want %q", rows, want)
+	}
+	dst := make([]string, 0, 4)
+	r = DefaultReader(strings.NewReader("x,y\n"))
+	if row, err = r.ScanRow(dst); err != nil || len(row) != 2 || &row[0] != &dst[:1][0] {
+		t.Errorf("got (%q, %v); want the caller slice to be reused", row, err)
+	}
+}
+
+func TestFieldsPerRecord(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,b\n\nc,\"d\nd\"\ne,f,g\n"))
+	r.FieldsPerRecord = 2
+	var records [][]string
+	for {
+		record, err := r.Record()
+		if err == io.EOF {
+			t.Fatal("error expected")
+		} else if err != nil {
+			var pe *ParseError
+			var ae *ArityError
+			if !errors.As(err, &pe) || pe.Line != 5 || pe.Record != 3 || pe.Column != 3 {
+				t.Errorf("got %#v; want a *ParseError on line 5, record 3, column 3", err)
+			} else if !errors.As(err, &ae) || ae.Got != 3 || ae.Want != 2 || !errors.Is(err, ErrTooManyFields) {
+				t.Errorf("got %v; want an *ArityError wrapping %v", err, ErrTooManyFields)
+			}
+			break
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Errorf("got %q; want 2 records", records)
+	}
+	if r.Scan() {
+		t.Error("scan should stop after a field count error")
+	}
+	r = DefaultReader(strings.NewReader("a,b\nc\n"))
+	r.FieldsPerRecord = -1
+	for _, err := r.Record(); err != io.EOF; _, err = r.Record() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestUnreadRecord(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,b\n1,2\n"))
+	if err := r.UnreadRecord(); err == nil {
+		t.Error("error expected")
+	}
+	record, err := r.Record()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.UnreadRecord(); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.UnreadRecord(); err == nil {
+		t.Error("error expected")
+	}
+	again, err := r.Record()
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(record, again) {
+		t.Errorf("got %q; want %q", again, record)
+	}
+	if err = r.UnreadRecord(); err != nil {
+		t.Fatal(err)
+	}
+	var values [2]string
+	if n, err := r.ScanRecord(&values[0], &values[1]); err != nil || n != 2 || values != [2]string{"a", "b"} {
+		t.Errorf("got %d, %v, %q; want %q", n, err, values, record)
+	}
+	if n, err := r.ScanRecord(&values[0], &values[1]); err != nil || n != 2 || values != [2]string{"1", "2"} {
+		t.Errorf("got %d, %v, %q; want %q", n, err, values, []string{"1", "2"})
+	}
+}
+
+func TestReadAllMaps(t *testing.T) {
+	r := DefaultReader(strings.NewReader("\nkey,value,key\na,1\n\nb,2,c,d\n"))
+	maps, err := ReadAllMaps(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first duplicated name wins (like ReadHeader)
+	want := []map[string]string{{"key": "a", "value": "1"}, {"key": "b", "value": "2", "3": "c", "4": "d"}}
+	if !reflect.DeepEqual(maps, want) {
+		t.Errorf("got %q; want %q", maps, want)
+	}
+	if maps, err = ReadAllMaps(DefaultReader(strings.NewReader(""))); err != nil || maps != nil {
+		t.Errorf("got (%q, %v); want (nil, nil)", maps, err)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	r := DefaultReader(strings.NewReader("id,qty,unit\n\n1,,\n2,3,kg\n,,\n"))
+	r.Defaults = map[string]string{"qty": "0", "unit": "piece"}
+	r.ColumnDefaults = []string{"", "", "unit"} // overrides Defaults
+	if err := r.ScanHeaders(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for {
+		var id string
+		var qty int
+		var unit string
+		if n, err := r.ScanRecord(&id, &qty, &unit); err != nil {
+			t.Fatal(err)
+		} else if n == 0 {
+			break
+		}
+		got = append(got, id+":"+strconv.Itoa(qty)+":"+unit)
+	}
+	if want := "1:0:unit,2:3:kg,:0:unit"; strings.Join(got, ",") != want {
+		t.Errorf("got %q; want %q", strings.Join(got, ","), want)
+	}
+}
+
+func TestSetColumnDecoder(t *testing.T) {
+	r := DefaultReader(strings.NewReader("alice,Paris,30\nbob,,x\n"))
+	r.ColumnDefaults = []string{"", "unknown"}
+	r.SetColumnDecoder(1, func(b []byte) ([]byte, error) { return bytes.ToUpper(b), nil })
+	r.SetColumnDecoder(2, func(b []byte) ([]byte, error) {
+		if _, err := strconv.Atoi(string(b)); err != nil {
+			return nil, err
+		}
+		return b, nil
+	})
+	record, err := r.Record()
+	if err != nil {
+		t.Fatal(err)
+	} else if want := []string{"alice", "PARIS", "30"}; !reflect.DeepEqual(record, want) {
+		t.Errorf("got %q; want %q", record, want)
+	}
+	if err = r.UnreadRecord(); err != nil {
+		t.Fatal(err)
+	} else if again, _ := r.Record(); !reflect.DeepEqual(again, record) { // not decoded twice
+		t.Errorf("got %q; want %q", again, record)
+	}
+	record, err = r.Record()
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Record != 2 || fe.Column != 3 {
+		t.Errorf("got (%q, %v); want a *FieldError on record 2, column 3", record, err)
+	} else if r.Scan() {
+		t.Error("scan should stop after a decoding error")
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	r := DefaultReader(strings.NewReader("\nid,name,name\n1,a,b\n2\n"))
+	header, err := r.ReadHeader()
+	if err != nil {
+		t.Fatal(err)
+	} else if want := []string{"id", "name", "name"}; !reflect.DeepEqual(header, want) || !reflect.DeepEqual(r.Header(), want) {
+		t.Errorf("got %q (%q); want %q", header, r.Header(), want)
+	}
+	if _, ok := r.FieldByName("id"); ok {
+		t.Error("no field expected before the first record")
+	}
+	if _, err = r.Record(); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := r.FieldByName("name"); !ok || v != "a" { // first duplicated name wins
+		t.Errorf("got (%q, %t); want (%q, true)", v, ok, "a")
+	}
+	if _, ok := r.FieldByName("age"); ok {
+		t.Error("unknown column")
+	}
+	if _, err = r.Record(); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := r.FieldByName("id"); !ok || v != "2" {
+		t.Errorf("got (%q, %t); want (%q, true)", v, ok, "2")
+	}
+	if _, ok := r.FieldByName("name"); ok {
+		t.Error("missing field")
+	}
+}
+
+func TestNamedAccessors(t *testing.T) {
+	r := DefaultReader(strings.NewReader("name,age,height\nbob,42,1.8\nalice,x\n"))
+	if _, err := r.String("name"); err == nil {
+		t.Error("error expected without headers")
+	}
+	if err := r.ScanHeaders(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.String("name"); err == nil {
+		t.Error("error expected without record")
+	}
+	if _, err := r.Record(); err != nil {
+		t.Fatal(err)
+	}
+	if name, err := r.String("name"); err != nil || name != "bob" {
+		t.Errorf("got (%q, %v); want (%q, nil)", name, err, "bob")
+	}
+	if age, err := r.Int("age"); err != nil || age != 42 {
+		t.Errorf("got (%d, %v); want (%d, nil)", age, err, 42)
+	}
+	if height, err := r.Float("height"); err != nil || height != 1.8 {
+		t.Errorf("got (%g, %v); want (%g, nil)", height, err, 1.8)
+	}
+	if _, err := r.String("weight"); err == nil {
+		t.Error("error expected for unknown column")
+	}
+	if _, err := r.Record(); err != nil {
+		t.Fatal(err)
+	}
+	var fe *FieldError
+	if _, err := r.Int("age"); !errors.As(err, &fe) || fe.Record != 3 || fe.Name != "age" || fe.Text != "x" {
+		t.Errorf("got %v; want a *FieldError on record 3, column age", err)
+	}
+	if _, err := r.Float("height"); !errors.Is(err, ErrTooFewFields) {
+		t.Errorf("got %v; want %v", err, ErrTooFewFields)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	r := DefaultReader(strings.NewReader("1,a,\"b,c\"\n2\n3,d"))
+	var i int
+	for _, want := range [][]string{{"a", "b,c"}, nil, {"d"}} {
+		if err := r.ScanValue(&i); err != nil {
+			t.Fatal(err)
+		}
+		values, err := r.Strings()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(values, want) {
+			t.Errorf("got %q; want %q", values, want)
+		}
+	}
+}
+
 var recordTests = []struct {
 	Name  string
 	Input string
@@ -0,0 +1,105 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import "bytes"
+
+// resync skips a malformed quoted field starting at data[0] (see OnResync):
+// the input is skipped until the first line from which the following records are well-formed
+// (balanced quotes and, once known, the same number of fields as the first record),
+// or until the end of the input when there is none.
+func (s *Reader) resync(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	end := -1
+	for i := bytes.IndexByte(data, '\n'); i >= 0; {
+		ok, more := s.wellFormed(data[i+1:], atEOF)
+		if more {
+			return 0, nil, nil // request more data
+		} else if ok {
+			end = i + 1
+			break
+		}
+		j := bytes.IndexByte(data[i+1:], '\n')
+		if j < 0 {
+			break
+		}
+		i += j + 1
+	}
+	if end < 0 {
+		if !atEOF {
+			return 0, nil, nil // request more data
+		}
+		end = len(data)
+	}
+	s.lineno += bytes.Count(data[:end], newline)
+	s.eor = true
+	s.OnResync(s.offset, s.offset+int64(end))
+	return end, data[:0], nil
+}
+
+// wellFormed tells if data starts with guessLines well-formed records
+// (less at the end of the input, more is returned when additional data is needed to decide).
+// Empty lines are ignored.
+func (s *Reader) wellFormed(data []byte, atEOF bool) (ok, more bool) {
+	q := s.quoteChar()
+	records, fields := 0, 1
+	start, quoted := true, false // at the start of a field, in a quoted field
+	eol := func(i int) bool {    // end of a record (or an empty line) at i
+		if fields == 1 && start && (i == 0 || data[i-1] == '\n' || data[i-1] == '\r' && (i == 1 || data[i-2] == '\n')) {
+			return true // empty line
+		} else if s.width > 0 && fields != s.width {
+			return false
+		}
+		records++
+		fields = 1
+		return true
+	}
+	for i := 0; i < len(data) && records < guessLines; i++ {
+		c := data[i]
+		if quoted {
+			if c != q {
+				continue
+			} else if i+1 == len(data) { // closing quote (unless followed by an escaped quote)
+				quoted = !atEOF
+				continue
+			} else if data[i+1] == q { // escaped quote
+				i++
+				continue
+			} else if n := data[i+1]; n != s.sep && n != '\n' && n != '\r' {
+				return false, false
+			}
+			quoted = false
+			continue
+		}
+		if start && s.quoted && c == q {
+			start, quoted = false, true
+			continue
+		}
+		switch c {
+		case s.sep:
+			fields++
+			start = true
+		case '\n':
+			if !eol(i) {
+				return false, false
+			}
+			start = true
+		case '\r':
+		default:
+			start = false
+		}
+	}
+	if records == guessLines {
+		return true, false
+	} else if !atEOF {
+		return false, true
+	} else if quoted {
+		return false, false
+	} else if !start || fields > 1 { // last record not terminated by a newline
+		if !eol(len(data)) {
+			return false, false
+		}
+	}
+	return records > 0, false
+}
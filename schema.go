@@ -0,0 +1,218 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnType is the type of a column's values.
+type ColumnType int
+
+// Column types
+const (
+	TypeText ColumnType = iota
+	TypeInt
+	TypeFloat
+	TypeBool
+	TypeTime
+)
+
+var columnTypeNames = []string{"text", "int", "float", "bool", "time"}
+
+func (t ColumnType) String() string {
+	if t < 0 || int(t) >= len(columnTypeNames) {
+		return "ColumnType(" + strconv.Itoa(int(t)) + ")"
+	}
+	return columnTypeNames[t]
+}
+
+// ColumnDef describes one column of a Schema.
+type ColumnDef struct {
+	Name     string
+	Type     ColumnType
+	Nullable bool // empty fields are converted to NULL/nil
+}
+
+// Schema describes the columns of a CSV file.
+type Schema []ColumnDef
+
+// timeLayouts are the layouts tried (in order) to parse TypeTime values.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// Convert converts text to the column type:
+// int64, float64, bool, time.Time or string (nil for empty text when the column is nullable).
+func (c ColumnDef) Convert(text []byte) (interface{}, error) {
+	if len(text) == 0 && c.Nullable {
+		return nil, nil
+	}
+	switch c.Type {
+	case TypeInt:
+		return strconv.ParseInt(string(text), 10, 64)
+	case TypeFloat:
+		return strconv.ParseFloat(string(text), 64)
+	case TypeBool:
+		return strconv.ParseBool(string(text))
+	case TypeTime:
+		var err error
+		for _, layout := range timeLayouts {
+			var t time.Time
+			if t, err = time.Parse(layout, string(text)); err == nil {
+				return t, nil
+			}
+		}
+		return nil, err
+	default:
+		return string(text), nil
+	}
+}
+
+// Names returns the columns name.
+func (s Schema) Names() []string {
+	names := make([]string, len(s))
+	for i, c := range s {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// ScanAnyMap returns the next record as a map by column name, with values converted according to Schema
+// (see ColumnDef.Convert) or, when Schema is nil, to the type inferred from each value
+// (int64, float64, bool, time.Time or string).
+// When Schema is nil, columns are named by Headers (the header record being scanned first when Headers is nil).
+// Extra fields are named by their column number (first is 1) and missing ones are absent.
+// Empty lines are skipped. It returns (nil, io.EOF) when there is no more record.
+func (s *Reader) ScanAnyMap() (map[string]interface{}, error) {
+	var names []string
+	if s.Schema != nil {
+		names = s.Schema.Names()
+	} else {
+		if s.Headers == nil {
+			if err := s.ScanHeaders(); err != nil {
+				return nil, err
+			}
+		}
+		names = headerNames(s.Headers)
+	}
+	values, err := s.Record()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		name := strconv.Itoa(i + 1)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		if i >= len(s.Schema) {
+			m[name] = inferValue(value)
+		} else if m[name], err = s.Schema[i].Convert([]byte(value)); err != nil {
+			return nil, &FieldError{Record: s.recno, Column: i + 1, Name: name, Text: value, Err: err}
+		}
+	}
+	return m, nil
+}
+
+// inferValue converts text to int64, float64, bool (true or false, case insensitive), time.Time (see timeLayouts)
+// or string (when no other type matches).
+func inferValue(text string) interface{} {
+	if text == "" {
+		return text
+	} else if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i
+	} else if f, err := strconv.ParseFloat(text, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f
+	} else if strings.EqualFold(text, "true") {
+		return true
+	} else if strings.EqualFold(text, "false") {
+		return false
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t
+		}
+	}
+	return text
+}
+
+type rows struct {
+	r      *Reader
+	schema Schema
+	names  []string
+	buf    []byte
+	fields [][]byte
+	recno  int
+}
+
+// AsRows exposes r's records as driver.Rows.
+// Fields are converted according to schema (see ColumnDef.Convert).
+// When schema is nil, the first record is used as header and all columns are TypeText.
+func AsRows(r *Reader, schema Schema) (driver.Rows, error) {
+	rs := &rows{r: r, schema: schema}
+	if schema == nil {
+		var err error
+		if rs.buf, rs.fields, err = r.readRecord(rs.buf, rs.fields); err != nil {
+			return nil, err
+		}
+		for _, field := range rs.fields {
+			rs.schema = append(rs.schema, ColumnDef{Name: string(field)})
+		}
+	}
+	rs.names = rs.schema.Names()
+	return rs, nil
+}
+
+func (rs *rows) Columns() []string {
+	return rs.names
+}
+
+func (rs *rows) Close() error {
+	return nil
+}
+
+func (rs *rows) Next(dest []driver.Value) error {
+	var err error
+	if rs.buf, rs.fields, err = rs.r.readRecord(rs.buf, rs.fields); err != nil {
+		return err
+	} else if rs.fields == nil {
+		return io.EOF
+	}
+	rs.recno++
+	if len(rs.fields) != len(rs.schema) {
+		return fmt.Errorf("yacr: record %d: got %d field(s), want %d", rs.recno, len(rs.fields), len(rs.schema))
+	}
+	for i, c := range rs.schema {
+		if dest[i], err = c.Convert(rs.fields[i]); err != nil {
+			return fmt.Errorf("yacr: record %d, column %q: %v", rs.recno, c.Name, err)
+		}
+	}
+	return nil
+}
+
+var scanTypes = []reflect.Type{
+	reflect.TypeOf(""), reflect.TypeOf(int64(0)), reflect.TypeOf(float64(0)), reflect.TypeOf(false), reflect.TypeOf(time.Time{}),
+}
+
+// ColumnTypeScanType implements driver.RowsColumnTypeScanType.
+func (rs *rows) ColumnTypeScanType(index int) reflect.Type {
+	return scanTypes[rs.schema[index].Type]
+}
+
+// ColumnTypeDatabaseTypeName implements driver.RowsColumnTypeDatabaseTypeName.
+func (rs *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return rs.schema[index].Type.String()
+}
+
+// ColumnTypeNullable implements driver.RowsColumnTypeNullable.
+func (rs *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return rs.schema[index].Nullable, true
+}
@@ -0,0 +1,104 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestAsRows(t *testing.T) {
+	schema := Schema{
+		{Name: "id", Type: TypeInt},
+		{Name: "price", Type: TypeFloat, Nullable: true},
+		{Name: "ok", Type: TypeBool},
+		{Name: "day", Type: TypeTime},
+		{Name: "name", Type: TypeText},
+	}
+	r := DefaultReader(strings.NewReader("1,3.14,true,2020-01-02,a\n\n2,,false,1970-01-01T00:00:00Z,\"b,c\"\n"))
+	rows, err := AsRows(r, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows.Columns(), []string{"id", "price", "ok", "day", "name"}) {
+		t.Errorf("unexpected columns: %v", rows.Columns())
+	}
+	want := [][]driver.Value{
+		{int64(1), 3.14, true, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), "a"},
+		{int64(2), nil, false, time.Unix(0, 0).UTC(), "b,c"},
+	}
+	dest := make([]driver.Value, len(schema))
+	for i := 0; ; i++ {
+		if err = rows.Next(dest); err == io.EOF {
+			if i != len(want) {
+				t.Errorf("got %d rows; want %d", i, len(want))
+			}
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(dest, want[i]) {
+			t.Errorf("got %v; want %v", dest, want[i])
+		}
+	}
+}
+
+func TestAsRowsHeader(t *testing.T) {
+	rows, err := AsRows(DefaultReader(strings.NewReader("a,b\n1,x\n1\n")), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows.Columns(), []string{"a", "b"}) {
+		t.Errorf("unexpected columns: %v", rows.Columns())
+	}
+	dest := make([]driver.Value, 2)
+	if err = rows.Next(dest); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(dest, []driver.Value{"1", "x"}) {
+		t.Errorf("unexpected values: %v", dest)
+	}
+	if err = rows.Next(dest); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestScanAnyMap(t *testing.T) {
+	r := DefaultReader(strings.NewReader("id,name,score,ok,day\n\n1,bob,1.5,TRUE,2024-03-01,x\n2,,NaN\n"))
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, want := range []map[string]interface{}{
+		{"id": int64(1), "name": "bob", "score": 1.5, "ok": true, "day": day, "6": "x"},
+		{"id": int64(2), "name": "", "score": "NaN"},
+	} {
+		if m, err := r.ScanAnyMap(); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(m, want) {
+			t.Errorf("got %v; want %v", m, want)
+		}
+	}
+	if m, err := r.ScanAnyMap(); err != io.EOF || m != nil {
+		t.Errorf("got (%v, %v); want (nil, EOF)", m, err)
+	}
+
+	r = DefaultReader(strings.NewReader("1,2\n"))
+	r.Schema = Schema{{Name: "id", Type: TypeText}, {Name: "n", Type: TypeInt}}
+	if m, err := r.ScanAnyMap(); err != nil {
+		t.Fatal(err)
+	} else if want := map[string]interface{}{"id": "1", "n": int64(2)}; !reflect.DeepEqual(m, want) {
+		t.Errorf("got %v; want %v", m, want)
+	}
+	r = DefaultReader(strings.NewReader("1,x\n"))
+	r.Schema = Schema{{Name: "id", Type: TypeText}, {Name: "n", Type: TypeInt}}
+	var fe *FieldError
+	if _, err := r.ScanAnyMap(); !errors.As(err, &fe) || fe.Name != "n" {
+		t.Errorf("got %v; want a *FieldError on column n", err)
+	}
+}
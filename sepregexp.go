@@ -0,0 +1,56 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+// scanRegexpField is the split path used when SepRegexp is specified:
+// fields are delimited by the first non-empty match in the current line.
+func (s *Reader) scanRegexpField(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 && s.eor {
+		return 0, nil, nil
+	}
+	end, next := -1, len(data)
+	for i, c := range data {
+		if c == '\n' {
+			end, next = i, i+1
+			break
+		}
+	}
+	if end < 0 {
+		if !atEOF {
+			return 0, nil, nil // request more data
+		}
+		end = len(data)
+	}
+	line := data[:end]
+	for off := 0; off < len(line); {
+		loc := s.SepRegexp.FindIndex(line[off:])
+		if loc == nil {
+			break
+		} else if loc[1] > loc[0] {
+			s.eor = false
+			return off + loc[1], s.trimField(line[:off+loc[0]]), nil
+		}
+		off += loc[1] + 1 // skip empty match
+	}
+	if end < len(data) {
+		s.lineno++
+		if end > 0 && line[end-1] == '\r' {
+			line = line[:end-1]
+			s.crlf++
+		} else {
+			s.lf++
+		}
+	}
+	s.eor = true
+	return next, s.trimField(line), nil
+}
+
+// trimField trims spaces when Trim is set.
+func (s *Reader) trimField(field []byte) []byte {
+	if s.Trim {
+		return trim(field)
+	}
+	return field
+}
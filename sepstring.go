@@ -0,0 +1,88 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// scanStringSepField is the split path used when SepString is specified:
+// fields are delimited by SepString (instead of sep), quoted fields (rfc4180) are supported in quoted mode.
+func (s *Reader) scanStringSepField(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 && s.eor {
+		return 0, nil, nil
+	}
+	sep := s.SepString
+	q := s.quoteChar()
+	if s.quoted && len(data) > 0 && data[0] == q {
+		escapedQuotes := 0
+		for i := 1; ; {
+			j := bytes.IndexByte(data[i:], q)
+			if j < 0 {
+				break
+			}
+			i += j
+			rest := data[i+1:]
+			if len(rest) > 0 && rest[0] == q { // escaped quote
+				escapedQuotes++
+				i += 2
+				continue
+			}
+			var end int // length of the terminator following the closing quote
+			switch {
+			case len(rest) == 0 && atEOF:
+				s.eor = true
+			case hasPrefix(rest, sep):
+				s.eor, end = false, len(sep)
+			case len(rest) > 0 && rest[0] == '\n':
+				s.eor, end = true, 1
+				s.lf++
+			case len(rest) > 1 && rest[0] == '\r' && rest[1] == '\n':
+				s.eor, end = true, 2
+				s.crlf++
+			case !atEOF && (strings.HasPrefix(sep, bytesString(rest)) || len(rest) == 1 && rest[0] == '\r'):
+				return 0, nil, nil // request more data
+			default:
+				return 0, nil, fmt.Errorf("unescaped %c character at line %d", q, s.lineno+bytes.Count(data[1:i], newline))
+			}
+			s.lineno += bytes.Count(data[1:i+1+end], newline)
+			return i + 1 + end, s.quotedValue(data[1:i], q, escapedQuotes, true), nil
+		}
+		if atEOF {
+			return 0, nil, fmt.Errorf("non-terminated quoted field between lines %d and %d", s.lineno, s.lineno+bytes.Count(data, newline))
+		}
+		return 0, nil, nil
+	}
+	end := bytes.IndexByte(data, '\n')
+	line := data
+	if end >= 0 {
+		line = data[:end]
+	}
+	if i := strings.Index(bytesString(line), sep); i >= 0 {
+		s.eor = false
+		return i + len(sep), s.trimField(line[:i]), nil
+	} else if end < 0 && !atEOF {
+		return 0, nil, nil // request more data
+	}
+	s.eor = true
+	if end < 0 {
+		return len(data), s.trimField(line), nil
+	}
+	s.lineno++
+	if end > 0 && line[end-1] == '\r' {
+		line = line[:end-1]
+		s.crlf++
+	} else {
+		s.lf++
+	}
+	return end + 1, s.trimField(line), nil
+}
+
+// hasPrefix tells if b starts with prefix.
+func hasPrefix(b []byte, prefix string) bool {
+	return len(b) >= len(prefix) && string(b[:len(prefix)]) == prefix
+}
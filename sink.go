@@ -0,0 +1,175 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+)
+
+// RecordSink is the interface implemented by record consumers (CSV writer, exporters, ...).
+type RecordSink interface {
+	// WriteFields consumes one record.
+	// fields content may be overwritten once the call returns.
+	WriteFields(fields [][]byte) error
+}
+
+// Transform modifies a record during a Copy.
+// It returns the fields to send to the sink, or nil to drop the record.
+// The returned fields may be (or reference) the input ones.
+type Transform func(fields [][]byte) ([][]byte, error)
+
+// Copy reads all records from r, applies transforms (in order) and sends the result to sink.
+// Empty lines are skipped.
+// It returns the number of records copied (dropped records excluded).
+func Copy(sink RecordSink, r *Reader, transforms ...Transform) (n int64, err error) {
+	var buf []byte
+	var fields, out [][]byte
+	for {
+		buf, fields, err = r.readRecord(buf, fields)
+		if err != nil || fields == nil {
+			return
+		}
+		out = fields
+		for _, t := range transforms {
+			if out, err = t(out); err != nil {
+				return
+			} else if out == nil {
+				break
+			}
+		}
+		if out == nil {
+			continue
+		}
+		if err = sink.WriteFields(out); err != nil {
+			return
+		}
+		n++
+	}
+}
+
+// Projection returns a Transform selecting (and reordering) fields by index (first is 0).
+// Missing fields are replaced by empty ones.
+func Projection(indexes ...int) Transform {
+	var out [][]byte
+	return func(fields [][]byte) ([][]byte, error) {
+		out = out[:0]
+		for _, i := range indexes {
+			if i < len(fields) {
+				out = append(out, fields[i])
+			} else {
+				out = append(out, []byte{})
+			}
+		}
+		return out, nil
+	}
+}
+
+// Filter returns a Transform dropping records for which keep returns false.
+func Filter(keep func(fields [][]byte) bool) Transform {
+	return func(fields [][]byte) ([][]byte, error) {
+		if keep(fields) {
+			return fields, nil
+		}
+		return nil, nil
+	}
+}
+
+// SourceColumn returns a Transform appending the name of the input of each record (see Reader.Source) as an extra field.
+// When header is not empty, it is appended to the first record instead.
+func SourceColumn(r *Reader, header string) Transform {
+	first := header != ""
+	var out [][]byte
+	return func(fields [][]byte) ([][]byte, error) {
+		out = append(out[:0], fields...)
+		if first {
+			first = false
+			return append(out, []byte(header)), nil
+		}
+		return append(out, []byte(r.Source())), nil
+	}
+}
+
+// ChecksumColumn returns a Transform appending the hex encoded hash (computed by newHash, like sha1.New)
+// of the selected fields (by index, first is 0) or of the whole record when no index is specified.
+// Fields are canonicalized by prefixing each one with its length (so that "a,bc" and "ab,c" differ);
+// missing fields are hashed as empty ones.
+// When header is not empty, it is appended to the first record instead.
+func ChecksumColumn(newHash func() hash.Hash, header string, indexes ...int) Transform {
+	first := header != ""
+	h := newHash()
+	var out [][]byte
+	var buf []byte
+	var size [binary.MaxVarintLen64]byte
+	write := func(field []byte) {
+		h.Write(size[:binary.PutUvarint(size[:], uint64(len(field)))])
+		h.Write(field)
+	}
+	return func(fields [][]byte) ([][]byte, error) {
+		out = append(out[:0], fields...)
+		if first {
+			first = false
+			return append(out, []byte(header)), nil
+		}
+		h.Reset()
+		if len(indexes) == 0 {
+			for _, field := range fields {
+				write(field)
+			}
+		} else {
+			for _, i := range indexes {
+				if i < len(fields) {
+					write(fields[i])
+				} else {
+					write(nil)
+				}
+			}
+		}
+		buf = h.Sum(buf[:0])
+		n := len(buf)
+		buf = append(buf, make([]byte, hex.EncodedLen(n))...)
+		hex.Encode(buf[n:], buf[:n])
+		return append(out, buf[n:]), nil
+	}
+}
+
+// fieldWriter is implemented by Writer, XMLWriter, MarkdownWriter and AlignedWriter.
+type fieldWriter interface {
+	Write(value []byte) bool
+	EndOfRecord()
+	Err() error
+}
+
+// writeFields writes fields as one record to w.
+func writeFields(w fieldWriter, fields [][]byte) error {
+	for _, field := range fields {
+		if !w.Write(field) {
+			return w.Err()
+		}
+	}
+	w.EndOfRecord()
+	return w.Err()
+}
+
+// WriteFields writes one record (it implements RecordSink).
+func (w *Writer) WriteFields(fields [][]byte) error {
+	return writeFields(w, fields)
+}
+
+// WriteFields writes one record (it implements RecordSink).
+func (w *XMLWriter) WriteFields(fields [][]byte) error {
+	return writeFields(w, fields)
+}
+
+// WriteFields writes one record (it implements RecordSink).
+func (w *MarkdownWriter) WriteFields(fields [][]byte) error {
+	return writeFields(w, fields)
+}
+
+// WriteFields writes one record (it implements RecordSink).
+func (w *AlignedWriter) WriteFields(fields [][]byte) error {
+	return writeFields(w, fields)
+}
@@ -0,0 +1,78 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestCopy(t *testing.T) {
+	input := "a,\"b\nb\",c\n\n\"d\"\"\",e\n" + strings.Repeat("x", 5000) + ",y"
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	n, err := Copy(w, DefaultReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	if n != 3 {
+		t.Errorf("got %d records; want %d", n, 3)
+	}
+	want := "a,\"b\nb\",c\n\"d\"\"\",e\n" + strings.Repeat("x", 5000) + ",y\n"
+	if out := b.String(); out != want {
+		t.Errorf("got %q; want %q", out, want)
+	}
+}
+
+func TestCopyTransforms(t *testing.T) {
+	input := "a,b,c\n1,2,3\n4,5\n6,7,8\n"
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	n, err := Copy(w, DefaultReader(strings.NewReader(input)),
+		Filter(func(fields [][]byte) bool { return string(fields[0]) != "6" }),
+		Projection(2, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	if n != 3 {
+		t.Errorf("got %d records; want %d", n, 3)
+	}
+	want := "c,a\n3,1\n,4\n"
+	if out := b.String(); out != want {
+		t.Errorf("got %q; want %q", out, want)
+	}
+}
+
+func TestChecksumColumn(t *testing.T) {
+	input := "id,a,b\n1,x,yz\n2,xy,z\n3,x,yz\n"
+	for _, indexes := range [][]int{nil, {1, 2}} {
+		b := &bytes.Buffer{}
+		w := DefaultWriter(b)
+		if _, err := Copy(w, DefaultReader(strings.NewReader(input)), ChecksumColumn(sha1.New, "sha1", indexes...)); err != nil {
+			t.Fatal(err)
+		}
+		w.Flush()
+		r := DefaultReader(b)
+		var sums []string
+		for {
+			values, err := r.Record()
+			if err != nil {
+				break
+			}
+			sums = append(sums, values[3])
+		}
+		if len(sums) != 4 || sums[0] != "sha1" || len(sums[1]) != 40 || sums[1] == sums[2] {
+			t.Errorf("%v: unexpected checksums: %q", indexes, sums)
+		} else if (sums[1] == sums[3]) != (indexes != nil) {
+			t.Errorf("%v: unexpected checksums: %q", indexes, sums)
+		}
+	}
+}
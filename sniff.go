@@ -0,0 +1,147 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+// defaultSniffSize is the sample size used by Sniff when none is specified.
+const defaultSniffSize = 64 << 10
+
+// sniffRecords is the maximum number of records following the first one used to detect a header row.
+const sniffRecords = 20
+
+// Sniff deduces the dialect of the CSV data read from r (like Python csv.Sniffer)
+// by looking at its first sampleSize bytes (64KB when not positive):
+// the separator (the candidate producing the most consistent number of fields by line),
+// the quote character (double or single quote) and whether values are quoted,
+// the line terminator, the comment character ('#' when a line starts with it)
+// and the presence of a header row (see Dialect.Header).
+// The sample is consumed: rewind r (or use io.MultiReader) before reading it with NewReaderDialect.
+func Sniff(r io.Reader, sampleSize int) (Dialect, error) {
+	if sampleSize <= 0 {
+		sampleSize = defaultSniffSize
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r, int64(sampleSize)))
+	if err != nil {
+		return Dialect{}, err
+	}
+	truncated := len(data) == sampleSize
+	var d Dialect
+	if i := bytes.IndexByte(data, '\n'); i > 0 && data[i-1] == '\r' {
+		d.CRLF = true
+	}
+	data = sniffComment(data, &d)
+	if len(bytes.TrimSpace(data)) == 0 {
+		return d, errors.New("yacr: cannot sniff the dialect of an empty sample")
+	}
+	for _, q := range []byte{'"', '\''} {
+		if sep := guess(data, q); sep != 0 {
+			if quoted, ok := guessQuoted(data, sep, q); ok && quoted {
+				d.Sep, d.Quoted = sep, true
+				if q != '"' {
+					d.Quote = q
+				}
+				break
+			}
+		}
+	}
+	if d.Sep == 0 {
+		if d.Sep = guess(data, 0); d.Sep == 0 {
+			return d, errors.New("yacr: could not determine the separator")
+		}
+	}
+	d.Header = sniffHeader(data, d, truncated)
+	return d, nil
+}
+
+// sniffComment removes the lines starting with '#' from data (setting d.Comment when there is one).
+func sniffComment(data []byte, d *Dialect) []byte {
+	if !bytes.HasPrefix(data, []byte{'#'}) && !bytes.Contains(data, []byte("\n#")) {
+		return data
+	}
+	d.Comment = '#'
+	b := make([]byte, 0, len(data))
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i+1]
+		}
+		data = data[len(line):]
+		if line[0] != '#' {
+			b = append(b, line...)
+		}
+	}
+	return b
+}
+
+// sniffHeader tells if the first record of data looks like a header (see HasHeader).
+// When truncated is true, the last record read may be incomplete and is ignored.
+func sniffHeader(data []byte, d Dialect, truncated bool) bool {
+	r := NewReaderDialect(bytes.NewReader(data), d)
+	var records [][]string
+	complete := false // true when the sample has been entirely read
+	for len(records) <= sniffRecords {
+		record, err := r.Record()
+		if err != nil {
+			complete = err == io.EOF
+			break
+		}
+		records = append(records, record)
+	}
+	if truncated && complete && len(records) > 0 {
+		records = records[:len(records)-1]
+	}
+	return HasHeader(records)
+}
+
+// HasHeader tells if the first of records (a sample of the first records of an input) looks like a header row.
+// For each column, the first value votes for a header when the following values are all numbers but not it
+// or all have the same length but not it, and against otherwise (like Python csv.Sniffer.has_header).
+// It returns false when there are less than two records.
+func HasHeader(records [][]string) bool {
+	if len(records) < 2 {
+		return false
+	}
+	votes := 0
+	for i, h := range records[0] {
+		numeric, length := true, -1 // length is -2 when values have different lengths
+		for _, record := range records[1:] {
+			if i >= len(record) {
+				continue
+			}
+			v := record[i]
+			if isNum, _ := IsNumber([]byte(v)); !isNum {
+				numeric = false
+			}
+			if length == -1 {
+				length = len(v)
+			} else if length != len(v) {
+				length = -2
+			}
+		}
+		if length == -1 { // no value
+			continue
+		}
+		if numeric {
+			if isNum, _ := IsNumber([]byte(h)); isNum {
+				votes--
+			} else {
+				votes++
+			}
+		} else if length >= 0 {
+			if len(h) != length {
+				votes++
+			} else {
+				votes--
+			}
+		}
+	}
+	return votes > 0
+}
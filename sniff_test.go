@@ -0,0 +1,68 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+var sniffTests = []struct {
+	Name    string
+	Input   string
+	Size    int
+	Dialect Dialect
+}{
+	{Name: "Simple", Input: "a,b\n1,2\n", Dialect: Dialect{Sep: ',', Header: true}},
+	{Name: "Header", Input: "id;name\n1;alice\n2;bob\n", Dialect: Dialect{Sep: ';', Header: true}},
+	{Name: "SameLength", Input: "code|city\nFR|Paris\nDE|Berlin\n", Dialect: Dialect{Sep: '|', Header: true}},
+	{Name: "NoHeader", Input: "1\t2\n3\t4\n", Dialect: Dialect{Sep: '\t'}},
+	{Name: "Quoted", Input: "name,desc\r\nbob,\"a;b;c\"\r\nalice,\"x;y\"\r\n", Dialect: Dialect{Sep: ',', Quoted: true, CRLF: true}},
+	{Name: "SingleQuote", Input: "n;v\n1;'a;b'\n2;'c'\n", Dialect: Dialect{Sep: ';', Quoted: true, Quote: '\'', Header: true}},
+	{Name: "Apostrophe", Input: "id,text\n1,it's\n2,don't\n", Dialect: Dialect{Sep: ',', Header: true}},
+	{Name: "Comment", Input: "# a; b; c, d\nx:y\n1:2\n", Dialect: Dialect{Sep: ':', Comment: '#', Header: true}},
+	{Name: "Truncated", Input: "x,yy\n1,aa\n2,bb\n3,cccccc\n", Size: 18, Dialect: Dialect{Sep: ','}}, // last record ignored
+}
+
+func TestSniff(t *testing.T) {
+	for _, tt := range sniffTests {
+		d, err := Sniff(strings.NewReader(tt.Input), tt.Size)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.Name, err)
+		} else if d != tt.Dialect {
+			t.Errorf("%s: got %+v; want %+v", tt.Name, d, tt.Dialect)
+		}
+	}
+	for _, input := range []string{"", "# comment\n", "abc\ndef\n"} {
+		if _, err := Sniff(strings.NewReader(input), 0); err == nil {
+			t.Errorf("%q: error expected", input)
+		}
+	}
+}
+
+var hasHeaderTests = []struct {
+	Name    string
+	Records [][]string
+	Header  bool
+}{
+	{Name: "Empty"},
+	{Name: "One", Records: [][]string{{"id", "name"}}},
+	{Name: "Numeric", Records: [][]string{{"id", "price"}, {"1", "3.5"}, {"2", "-1e3"}}, Header: true},
+	{Name: "NumericData", Records: [][]string{{"0", "1.5"}, {"1", "3.5"}, {"2", "-1e3"}}},
+	{Name: "Length", Records: [][]string{{"country"}, {"FR"}, {"DE"}}, Header: true},
+	{Name: "SameLength", Records: [][]string{{"FR"}, {"DE"}, {"IT"}}},
+	{Name: "Text", Records: [][]string{{"alice"}, {"bob"}, {"dave"}}}, // no vote
+	{Name: "Ragged", Records: [][]string{{"id", "tags"}, {"1"}, {"2", "a"}}, Header: true},
+}
+
+func TestHasHeader(t *testing.T) {
+	for _, tt := range hasHeaderTests {
+		if got := HasHeader(tt.Records); got != tt.Header {
+			t.Errorf("%s: got %t; want %t", tt.Name, got, tt.Header)
+		}
+	}
+}
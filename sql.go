@@ -0,0 +1,405 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ImportOptions controls ImportSQLite behaviour.
+type ImportOptions struct {
+	Skip      int // number of leading records to skip (like sqlite3 shell '.import --skip N')
+	BatchSize int // number of records inserted by transaction (all records in one transaction when <= 0)
+}
+
+// ImportSQLite imports records from r into table, like sqlite3 shell '.import' command:
+// when the table does not exist, it is created with the first record as column names (all columns are TEXT),
+// otherwise all records are inserted, missing fields are replaced by NULLs and extra fields are ignored.
+// Records are inserted with a prepared statement in one or more transactions.
+// It returns the number of inserted records.
+func ImportSQLite(db *sql.DB, table string, r *Reader, opts *ImportOptions) (int64, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	if err := r.SkipRecords(opts.Skip); err != nil {
+		return 0, err
+	}
+	var buf []byte
+	var fields [][]byte
+	var err error
+	var exists bool
+	if exists, err = sqliteTableExists(db, table); err != nil {
+		return 0, err
+	}
+	var ncol int
+	if exists {
+		rows, err := db.Query("SELECT * FROM " + quoteIdentifier(table) + " LIMIT 0")
+		if err != nil {
+			return 0, err
+		}
+		cols, err := rows.Columns()
+		_ = rows.Close()
+		if err != nil {
+			return 0, err
+		}
+		ncol = len(cols)
+	} else {
+		if buf, fields, err = r.readRecord(buf, fields); err != nil {
+			return 0, err
+		} else if fields == nil {
+			return 0, fmt.Errorf("yacr: no header to create table %s", table)
+		}
+		ncol = len(fields)
+		cols := make([]string, ncol)
+		for i, field := range fields {
+			cols[i] = quoteIdentifier(string(field)) + " TEXT"
+		}
+		if _, err = db.Exec("CREATE TABLE " + quoteIdentifier(table) + " (" + strings.Join(cols, ", ") + ")"); err != nil {
+			return 0, err
+		}
+	}
+	insert := "INSERT INTO " + quoteIdentifier(table) + " VALUES (?" + strings.Repeat(", ?", ncol-1) + ")"
+	args := make([]interface{}, ncol)
+	var n int64
+	for {
+		tx, err := db.Begin()
+		if err != nil {
+			return n, err
+		}
+		stmt, err := tx.Prepare(insert)
+		if err != nil {
+			_ = tx.Rollback()
+			return n, err
+		}
+		var count int
+		for opts.BatchSize <= 0 || count < opts.BatchSize {
+			if buf, fields, err = r.readRecord(buf, fields); err != nil || fields == nil {
+				break
+			}
+			for i := range args {
+				if i < len(fields) {
+					args[i] = string(fields[i])
+				} else {
+					args[i] = nil
+				}
+			}
+			if _, err = stmt.Exec(args...); err != nil {
+				break
+			}
+			count++
+		}
+		_ = stmt.Close()
+		if err != nil {
+			_ = tx.Rollback()
+			return n, err
+		}
+		if err = tx.Commit(); err != nil {
+			return n, err
+		}
+		n += int64(count)
+		if fields == nil {
+			return n, nil
+		}
+	}
+}
+
+// WriteRows writes rows column names as a header and then all rows.
+// NULLs are written as empty fields, []byte as is and time.Time in RFC3339 format.
+// It returns the number of written rows (header excluded).
+func (w *Writer) WriteRows(rows *sql.Rows) (int64, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	for _, col := range cols {
+		if !w.WriteString(col) {
+			return 0, w.err
+		}
+	}
+	w.EndOfRecord()
+	values := make([]interface{}, len(cols))
+	args := make([]interface{}, len(cols))
+	for i := range values {
+		args[i] = &values[i]
+	}
+	var n int64
+	for rows.Next() {
+		if err = rows.Scan(args...); err != nil {
+			return n, err
+		}
+		for _, value := range values {
+			if !w.WriteValue(value) {
+				return n, w.err
+			}
+		}
+		w.EndOfRecord()
+		if w.err != nil {
+			return n, w.err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+// Execer is the interface implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Copier may be implemented by an Execer supporting bulk copy (like Postgres COPY).
+type Copier interface {
+	CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error)
+}
+
+// SQLFlavor specifies the SQL syntax (placeholders, identifiers quoting) of a database.
+type SQLFlavor int
+
+// SQL flavors
+const (
+	FlavorSQLite   SQLFlavor = iota // ? placeholders and "ident"
+	FlavorPostgres                  // $n placeholders and "ident"
+	FlavorMySQL                     // ? placeholders and `ident`
+)
+
+// LoadOptions controls Load behaviour.
+type LoadOptions struct {
+	Columns     []string  // column names (by default, the first record is used as header)
+	BatchSize   int       // number of records by INSERT statement (100 by default)
+	Flavor      SQLFlavor // placeholders and identifiers quoting style
+	EmptyAsNull bool      // insert NULL instead of empty strings
+	Nulls       []string  // tokens (like `\N` or "NULL") inserted as NULL
+	Limit       int       // maximum number of inserted records (all when <= 0)
+}
+
+// Load inserts records from r into table by batch, with multi-row INSERT statements
+// or with CopyFrom when execer implements Copier.
+// Missing fields are replaced by NULLs and extra fields are ignored.
+// It returns the number of inserted records.
+func Load(ctx context.Context, execer Execer, table string, r *Reader, opts *LoadOptions) (int64, error) {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	var buf []byte
+	var fields [][]byte
+	var err error
+	columns := opts.Columns
+	if columns == nil {
+		if buf, fields, err = r.readRecord(buf, fields); err != nil || fields == nil {
+			return 0, err
+		}
+		columns = make([]string, len(fields))
+		for i, field := range fields {
+			columns[i] = string(field)
+		}
+	}
+	copier, _ := execer.(Copier)
+	var n int64
+	rows := make([][]interface{}, 0, batchSize)
+	for {
+		rows = rows[:0]
+		for len(rows) < batchSize && (opts.Limit <= 0 || n+int64(len(rows)) < int64(opts.Limit)) {
+			if buf, fields, err = r.readRecord(buf, fields); err != nil {
+				return n, err
+			} else if fields == nil {
+				break
+			}
+			row := make([]interface{}, len(columns))
+			for i := range row {
+				if i < len(fields) && (len(fields[i]) > 0 || !opts.EmptyAsNull) && !isNullToken(fields[i], opts.Nulls) {
+					row[i] = string(fields[i])
+				}
+			}
+			rows = append(rows, row)
+		}
+		if len(rows) == 0 {
+			return n, nil
+		}
+		if copier != nil {
+			var c int64
+			c, err = copier.CopyFrom(ctx, table, columns, rows)
+			n += c
+		} else {
+			err = insertRows(ctx, execer, table, columns, rows, opts.Flavor)
+			if err == nil {
+				n += int64(len(rows))
+			}
+		}
+		if err != nil || fields == nil {
+			return n, err
+		}
+	}
+}
+
+// isNullToken tells if field is one of nulls.
+func isNullToken(field []byte, nulls []string) bool {
+	for _, null := range nulls {
+		if string(field) == null {
+			return true
+		}
+	}
+	return false
+}
+
+func insertRows(ctx context.Context, execer Execer, table string, columns []string, rows [][]interface{}, flavor SQLFlavor) error {
+	var b strings.Builder
+	b.WriteString("INSERT INTO ")
+	b.WriteString(flavor.QuoteIdentifier(table))
+	b.WriteString(" (")
+	for i, column := range columns {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(flavor.QuoteIdentifier(column))
+	}
+	b.WriteString(") VALUES ")
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j := range row {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(flavor.Placeholder(len(args) + 1))
+			args = append(args, row[j])
+		}
+		b.WriteByte(')')
+	}
+	_, err := execer.ExecContext(ctx, b.String(), args...)
+	return err
+}
+
+// QuoteIdentifier quotes an SQL identifier (table or column name).
+func (f SQLFlavor) QuoteIdentifier(name string) string {
+	if f == FlavorMySQL {
+		return "`" + strings.Replace(name, "`", "``", -1) + "`"
+	}
+	return quoteIdentifier(name)
+}
+
+// Placeholder returns the placeholder of the n-th (first is 1) statement argument.
+func (f SQLFlavor) Placeholder(n int) string {
+	if f == FlavorPostgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// sqlTypes are the column types by flavor (indexed by ColumnType).
+var sqlTypes = [][]string{
+	FlavorSQLite:   {"TEXT", "INTEGER", "REAL", "BOOLEAN", "TIMESTAMP"},
+	FlavorPostgres: {"TEXT", "BIGINT", "DOUBLE PRECISION", "BOOLEAN", "TIMESTAMP"},
+	FlavorMySQL:    {"TEXT", "BIGINT", "DOUBLE", "BOOLEAN", "DATETIME"},
+}
+
+// CreateTable returns the statement creating table with the columns of s (see TypeInferrer),
+// non nullable columns being declared NOT NULL.
+func (s Schema) CreateTable(table string, flavor SQLFlavor) string {
+	var b strings.Builder
+	b.WriteString("CREATE TABLE ")
+	b.WriteString(flavor.QuoteIdentifier(table))
+	b.WriteString(" (")
+	for i, c := range s {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(flavor.QuoteIdentifier(c.Name))
+		b.WriteByte(' ')
+		b.WriteString(sqlTypes[flavor][c.Type])
+		if !c.Nullable {
+			b.WriteString(" NOT NULL")
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// InferCreateTable reads the header (unless already read, see Reader.ReadHeader) and then at most sample records
+// (all when not positive) from r, and returns the statement creating table with the columns inferred by a TypeInferrer.
+// Column names are sanitized (see SanitizeIdentifiers), the returned Schema using the same names
+// (to be used as LoadOptions.Columns). Records read are consumed: rewind the input (or use CloneFor) to load them.
+func InferCreateTable(r *Reader, table string, flavor SQLFlavor, sample int) (string, Schema, error) {
+	header := r.Header()
+	if header == nil {
+		var err error
+		if header, err = r.ReadHeader(); err != nil {
+			return "", nil, err
+		}
+	}
+	ti := &TypeInferrer{Records: sample, Nulls: r.Nulls}
+	schema, err := ti.Infer(r)
+	if err != nil {
+		return "", nil, err
+	}
+	names := make([]string, len(schema))
+	copy(names, header)
+	for i, name := range SanitizeIdentifiers(names) {
+		schema[i].Name = name
+	}
+	return schema.CreateTable(table, flavor), schema, nil
+}
+
+// SanitizeIdentifiers returns names usable as unquoted SQL identifiers:
+// names are lower-cased, runs of characters other than ASCII letters, digits and underscores are replaced by an underscore
+// (leading and trailing ones being removed), a name starting with a digit is prefixed by an underscore,
+// an empty name is replaced by "column_" followed by its position (first is 1)
+// and duplicated names are suffixed by "_2", "_3", ...
+func SanitizeIdentifiers(names []string) []string {
+	sanitized := make([]string, len(names))
+	seen := make(map[string]bool, len(names))
+	for i, name := range names {
+		var b strings.Builder
+		sep := false // true when a replaced character is pending
+		for _, c := range strings.ToLower(name) {
+			if c == '_' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+				if sep && b.Len() > 0 {
+					b.WriteByte('_')
+				}
+				sep = false
+				b.WriteRune(c)
+			} else {
+				sep = true
+			}
+		}
+		name = strings.Trim(b.String(), "_")
+		if name == "" {
+			name = "column_" + strconv.Itoa(i+1)
+		} else if name[0] >= '0' && name[0] <= '9' {
+			name = "_" + name
+		}
+		unique := name
+		for n := 2; seen[unique]; n++ {
+			unique = name + "_" + strconv.Itoa(n)
+		}
+		seen[unique] = true
+		sanitized[i] = unique
+	}
+	return sanitized
+}
+
+func sqliteTableExists(db *sql.DB, table string) (bool, error) {
+	rows, err := db.Query("SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?", table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	exists := rows.Next()
+	return exists, rows.Err()
+}
+
+// quoteIdentifier quotes an SQL identifier (table or column name).
+func quoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,193 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestImportSQLiteCreate(t *testing.T) {
+	db, fdb := openFake("import-create")
+	defer db.Close()
+	r := DefaultReader(strings.NewReader("a,\"b\"\"\"\n1,2\n3,4\n5,6\n"))
+	n, err := ImportSQLite(db, "t", r, &ImportOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("got %d; want %d", n, 3)
+	}
+	insert := `INSERT INTO "t" VALUES (?, ?)`
+	log := []string{`CREATE TABLE "t" ("a" TEXT, "b""" TEXT)`,
+		"BEGIN", insert, insert, "COMMIT", "BEGIN", insert, "COMMIT"}
+	if !reflect.DeepEqual(fdb.log, log) {
+		t.Errorf("got %q; want %q", fdb.log, log)
+	}
+	args := [][]driver.Value{{}, {"1", "2"}, {"3", "4"}, {"5", "6"}}
+	if !reflect.DeepEqual(fdb.args, args) {
+		t.Errorf("got %q; want %q", fdb.args, args)
+	}
+}
+
+func TestImportSQLiteExisting(t *testing.T) {
+	db, fdb := openFake("import-existing")
+	defer db.Close()
+	fdb.results["SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?"] = &fakeRows{cols: []string{"1"}, rows: [][]driver.Value{{int64(1)}}}
+	fdb.results[`SELECT * FROM "t" LIMIT 0`] = &fakeRows{cols: []string{"a", "b", "c"}}
+	r := DefaultReader(strings.NewReader("a,b,c\n1,2\n3,4,5,6\n"))
+	n, err := ImportSQLite(db, "t", r, &ImportOptions{Skip: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("got %d; want %d", n, 2)
+	}
+	args := [][]driver.Value{{"1", "2", nil}, {"3", "4", "5"}}
+	if !reflect.DeepEqual(fdb.args, args) {
+		t.Errorf("got %q; want %q", fdb.args, args)
+	}
+}
+
+func TestWriteRows(t *testing.T) {
+	db, fdb := openFake("write-rows")
+	defer db.Close()
+	fdb.results["SELECT * FROM t"] = &fakeRows{cols: []string{"id", "name", "data", "ts"}, rows: [][]driver.Value{
+		{int64(1), "a,b", []byte("x\"y"), time.Unix(0, 0).UTC()},
+		{int64(2), nil, nil, nil},
+		{3.5, true, "", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}}
+	rows, err := db.Query("SELECT * FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	n, err := w.WriteRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	if n != 3 {
+		t.Errorf("got %d; want %d", n, 3)
+	}
+	want := "id,name,data,ts\n1,\"a,b\",\"x\"\"y\",1970-01-01T00:00:00Z\n2,,,\n3.5,true,,2020-01-02T03:04:05Z\n"
+	if out := b.String(); out != want {
+		t.Errorf("got %q; want %q", out, want)
+	}
+}
+
+type fakeCopier struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+func (c *fakeCopier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errors.New("unexpected call to ExecContext")
+}
+
+func (c *fakeCopier) CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	c.columns = columns
+	c.rows = append(c.rows, rows...)
+	return int64(len(rows)), nil
+}
+
+func TestLoad(t *testing.T) {
+	for _, tt := range []struct {
+		Flavor SQLFlavor
+		Query  string
+	}{
+		{FlavorSQLite, `INSERT INTO "t" ("a", "b") VALUES (?, ?), (?, ?)`},
+		{FlavorPostgres, `INSERT INTO "t" ("a", "b") VALUES ($1, $2), ($3, $4)`},
+		{FlavorMySQL, "INSERT INTO `t` (`a`, `b`) VALUES (?, ?), (?, ?)"},
+	} {
+		db, fdb := openFake("load")
+		r := DefaultReader(strings.NewReader("a,b\n1,2\n3,\n5,6,7\n"))
+		n, err := Load(context.Background(), db, "t", r, &LoadOptions{BatchSize: 2, Flavor: tt.Flavor, EmptyAsNull: true})
+		db.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 3 {
+			t.Errorf("got %d; want %d", n, 3)
+		}
+		log := []string{tt.Query, strings.Replace(tt.Query, ", ($3, $4)", "", 1)}
+		log[1] = strings.Replace(log[1], ", (?, ?)", "", 1)
+		if !reflect.DeepEqual(fdb.log, log) {
+			t.Errorf("got %q; want %q", fdb.log, log)
+		}
+		args := [][]driver.Value{{"1", "2", "3", nil}, {"5", "6"}}
+		if !reflect.DeepEqual(fdb.args, args) {
+			t.Errorf("got %q; want %q", fdb.args, args)
+		}
+	}
+}
+
+func TestLoadCopier(t *testing.T) {
+	c := &fakeCopier{}
+	r := DefaultReader(strings.NewReader("1,2\n3,4\n5,6\n"))
+	n, err := Load(context.Background(), c, "t", r, &LoadOptions{Columns: []string{"a", "b"}, BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("got %d; want %d", n, 3)
+	}
+	rows := [][]interface{}{{"1", "2"}, {"3", "4"}, {"5", "6"}}
+	if !reflect.DeepEqual(c.rows, rows) || !reflect.DeepEqual(c.columns, []string{"a", "b"}) {
+		t.Errorf("got %q %q; want %q", c.columns, c.rows, rows)
+	}
+}
+
+func TestCreateTable(t *testing.T) {
+	schema := Schema{{Name: "id", Type: TypeInt}, {Name: "price", Type: TypeFloat, Nullable: true}, {Name: "day", Type: TypeTime}, {Name: "name", Nullable: true}}
+	for _, tt := range []struct {
+		Flavor SQLFlavor
+		Query  string
+	}{
+		{FlavorSQLite, `CREATE TABLE "t" ("id" INTEGER NOT NULL, "price" REAL, "day" TIMESTAMP NOT NULL, "name" TEXT)`},
+		{FlavorPostgres, `CREATE TABLE "t" ("id" BIGINT NOT NULL, "price" DOUBLE PRECISION, "day" TIMESTAMP NOT NULL, "name" TEXT)`},
+		{FlavorMySQL, "CREATE TABLE `t` (`id` BIGINT NOT NULL, `price` DOUBLE, `day` DATETIME NOT NULL, `name` TEXT)"},
+	} {
+		if got := schema.CreateTable("t", tt.Flavor); got != tt.Query {
+			t.Errorf("got %q; want %q", got, tt.Query)
+		}
+	}
+}
+
+func TestSanitizeIdentifiers(t *testing.T) {
+	names := []string{"Id", " Unit Price ($) ", "2024 total", "", "é", "a-b", "a_b", "a b", "_x_"}
+	want := []string{"id", "unit_price", "_2024_total", "column_4", "column_5", "a_b", "a_b_2", "a_b_3", "x"}
+	if got := SanitizeIdentifiers(names); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestInferCreateTable(t *testing.T) {
+	r := DefaultReader(strings.NewReader("Order ID,Amount,\n1,9.99,x\n2,,y,z\n3,x,z\n"))
+	ddl, schema, err := InferCreateTable(r, "orders", FlavorPostgres, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `CREATE TABLE "orders" ("order_id" BIGINT NOT NULL, "amount" DOUBLE PRECISION, "column_3" TEXT NOT NULL, "column_4" TEXT)`; ddl != want {
+		t.Errorf("got %q; want %q", ddl, want)
+	}
+	if names := schema.Names(); !reflect.DeepEqual(names, []string{"order_id", "amount", "column_3", "column_4"}) {
+		t.Errorf("got %q", names)
+	}
+	if _, _, err = InferCreateTable(DefaultReader(strings.NewReader("")), "t", FlavorSQLite, 0); err == nil {
+		t.Error("error expected without header")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sqlcsv streams CSV records into a database/sql table by transaction batches.
+package sqlcsv
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+
+	"github.com/gwenn/yacr"
+)
+
+// LoadOptions controls LoadIntoDB behaviour.
+type LoadOptions struct {
+	Columns   []string       // column names (by default, the first record is used as header)
+	BatchSize int            // number of records inserted by transaction (1000 by default)
+	Flavor    yacr.SQLFlavor // placeholders and identifiers quoting style
+	Nulls     []string       // tokens (like "" or `\N`) inserted as NULL
+	TxOptions *sql.TxOptions // options of the transactions (may be nil)
+}
+
+// LoadIntoDB inserts records from r into table (see yacr.Load), in one transaction every BatchSize records.
+// Missing fields are replaced by NULLs and extra fields are ignored.
+// On error, the current transaction is rolled back (previous batches remain committed).
+// It returns the number of committed records.
+func LoadIntoDB(ctx context.Context, db *sql.DB, table string, r *yacr.Reader, opts *LoadOptions) (int64, error) {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	columns := opts.Columns
+	if columns == nil {
+		fields, err := r.ReadRecord()
+		if err == io.EOF {
+			return 0, nil
+		} else if err != nil {
+			return 0, err
+		}
+		columns = make([]string, len(fields))
+		for i, field := range fields {
+			columns[i] = string(field)
+		}
+	}
+	if len(columns) == 0 {
+		return 0, errors.New("sqlcsv: no column")
+	}
+	lo := &yacr.LoadOptions{Columns: columns, Flavor: opts.Flavor, Nulls: opts.Nulls, Limit: batchSize}
+	var n int64
+	for {
+		tx, err := db.BeginTx(ctx, opts.TxOptions)
+		if err != nil {
+			return n, err
+		}
+		count, err := yacr.Load(ctx, tx, table, r, lo)
+		if err != nil {
+			_ = tx.Rollback()
+			return n, err
+		} else if count == 0 {
+			return n, tx.Rollback()
+		} else if err = tx.Commit(); err != nil {
+			return n, err
+		}
+		n += count
+		if count < int64(batchSize) {
+			return n, nil
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlcsv_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gwenn/yacr"
+	. "github.com/gwenn/yacr/sqlcsv"
+)
+
+// fakeConn records statements and their arguments (one connection by test).
+type fakeConn struct {
+	log    []string
+	args   [][]driver.Value
+	failAt int // Exec fails when the number of executed statements reaches failAt (unless 0)
+}
+
+type fakeConnector struct {
+	c *fakeConn
+}
+
+func (fc fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return fc.c, nil
+}
+func (fc fakeConnector) Driver() driver.Driver {
+	return nil
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.log = append(c.log, "PREPARE "+query)
+	return fakeStmt{c}, nil
+}
+func (c *fakeConn) Close() error {
+	return nil
+}
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.log = append(c.log, "BEGIN")
+	return c, nil
+}
+func (c *fakeConn) Commit() error {
+	c.log = append(c.log, "COMMIT")
+	return nil
+}
+func (c *fakeConn) Rollback() error {
+	c.log = append(c.log, "ROLLBACK")
+	return nil
+}
+
+type fakeStmt struct {
+	c *fakeConn
+}
+
+func (s fakeStmt) Close() error {
+	return nil
+}
+func (s fakeStmt) NumInput() int {
+	return -1
+}
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.c.failAt > 0 && len(s.c.args)+1 == s.c.failAt {
+		return nil, errors.New("exec failed")
+	}
+	s.c.args = append(s.c.args, args)
+	return driver.RowsAffected(1), nil
+}
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestLoadIntoDB(t *testing.T) {
+	c := &fakeConn{}
+	db := sql.OpenDB(fakeConnector{c})
+	defer db.Close()
+	r := yacr.DefaultReader(strings.NewReader("a,b\n1,2\n3,\\N\n5,6,7\n"))
+	n, err := LoadIntoDB(context.Background(), db, "t", r, &LoadOptions{BatchSize: 2, Flavor: yacr.FlavorPostgres, Nulls: []string{`\N`}})
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 3 {
+		t.Errorf("got %d; want %d", n, 3)
+	}
+	if log := []string{"BEGIN", `PREPARE INSERT INTO "t" ("a", "b") VALUES ($1, $2), ($3, $4)`, "COMMIT",
+		"BEGIN", `PREPARE INSERT INTO "t" ("a", "b") VALUES ($1, $2)`, "COMMIT"}; !reflect.DeepEqual(c.log, log) {
+		t.Errorf("got %q; want %q", c.log, log)
+	}
+	if args := [][]driver.Value{{"1", "2", "3", nil}, {"5", "6"}}; !reflect.DeepEqual(c.args, args) {
+		t.Errorf("got %q; want %q", c.args, args)
+	}
+
+	c = &fakeConn{failAt: 2}
+	db = sql.OpenDB(fakeConnector{c})
+	defer db.Close()
+	r = yacr.DefaultReader(strings.NewReader("1\n2\n3\n"))
+	if n, err = LoadIntoDB(context.Background(), db, "t", r, &LoadOptions{Columns: []string{"a"}, BatchSize: 2}); err == nil || n != 2 {
+		t.Errorf("got (%d, %v); want (2, error)", n, err)
+	} else if last := c.log[len(c.log)-1]; last != "ROLLBACK" {
+		t.Errorf("got %q; want ROLLBACK", last)
+	}
+}
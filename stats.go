@@ -0,0 +1,128 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"io"
+	"math"
+	"strconv"
+)
+
+// ColumnStats summarizes the values of one column (see Stats).
+type ColumnStats struct {
+	Name    string  // header name (empty when Stats.Header is not set)
+	Nulls   int64   // number of empty (or missing) values
+	Numbers int64   // number of numeric values (see IsNumber)
+	Min     float64 // minimum of numeric values
+	Max     float64 // maximum of numeric values
+	mean    float64 // running mean of numeric values
+	m2      float64 // running sum of squared differences from the mean (Welford's algorithm)
+}
+
+// Mean returns the mean of numeric values (NaN when there is none).
+func (c *ColumnStats) Mean() float64 {
+	if c.Numbers == 0 {
+		return math.NaN()
+	}
+	return c.mean
+}
+
+// StdDev returns the sample standard deviation of numeric values (NaN when there are less than two).
+func (c *ColumnStats) StdDev() float64 {
+	if c.Numbers < 2 {
+		return math.NaN()
+	}
+	return math.Sqrt(c.m2 / float64(c.Numbers-1))
+}
+
+func (c *ColumnStats) add(field []byte) {
+	if len(field) == 0 {
+		c.Nulls++
+		return
+	} else if isNum, _ := IsNumber(field); !isNum {
+		return
+	}
+	x, err := strconv.ParseFloat(bytesString(field), 64)
+	if err != nil { // out of range
+		return
+	}
+	c.Numbers++
+	if c.Numbers == 1 || x < c.Min {
+		c.Min = x
+	}
+	if c.Numbers == 1 || x > c.Max {
+		c.Max = x
+	}
+	d := x - c.mean
+	c.mean += d / float64(c.Numbers)
+	c.m2 += d * (x - c.mean)
+}
+
+// Stats computes streaming statistics (count, nulls, min/max, mean/stddev by column)
+// on the records going through a Copy (see Collect), in a single pass.
+type Stats struct {
+	Header  bool          // first record is a header (giving the columns name)
+	Count   int64         // number of records (header excluded)
+	Columns []ColumnStats // by field index
+	started bool
+}
+
+// Collect updates the statistics with one record and returns it unchanged (it is a Transform).
+// Put it after the transforms modifying the records to get the statistics of the output.
+func (st *Stats) Collect(fields [][]byte) ([][]byte, error) {
+	for len(st.Columns) < len(fields) {
+		st.Columns = append(st.Columns, ColumnStats{Nulls: st.Count}) // previous records were missing this column
+	}
+	if st.Header && !st.started {
+		st.started = true
+		for i, field := range fields {
+			st.Columns[i].Name = string(field)
+		}
+		return fields, nil
+	}
+	st.started = true
+	st.Count++
+	for i := range st.Columns {
+		if i < len(fields) {
+			st.Columns[i].add(fields[i])
+		} else {
+			st.Columns[i].Nulls++
+		}
+	}
+	return fields, nil
+}
+
+// WriteCSV writes the statistics as CSV, one record by column
+// (with a column,count,nulls,numbers,min,max,mean,stddev header).
+func (st *Stats) WriteCSV(w io.Writer) error {
+	wr := DefaultWriter(w)
+	wr.WriteRecord("column", "count", "nulls", "numbers", "min", "max", "mean", "stddev")
+	for i := range st.Columns {
+		c := &st.Columns[i]
+		name := c.Name
+		if name == "" {
+			name = strconv.Itoa(i + 1)
+		}
+		wr.WriteString(name)
+		wr.WriteValue(st.Count)
+		wr.WriteValue(c.Nulls)
+		wr.WriteValue(c.Numbers)
+		if c.Numbers == 0 {
+			wr.WriteRecord("", "", "", "")
+			continue
+		}
+		wr.WriteValue(c.Min)
+		wr.WriteValue(c.Max)
+		wr.WriteValue(c.Mean())
+		if c.Numbers < 2 {
+			wr.WriteString("")
+		} else {
+			wr.WriteValue(c.StdDev())
+		}
+		wr.EndOfRecord()
+	}
+	wr.Flush()
+	return wr.Err()
+}
@@ -0,0 +1,47 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestStats(t *testing.T) {
+	input := "id,name,price\n1,a,2\n2,,4\n3,c\n4,d,x\n5,e,9,extra\n"
+	var out bytes.Buffer
+	w := DefaultWriter(&out)
+	st := &Stats{Header: true}
+	n, err := Copy(w, DefaultReader(strings.NewReader(input)), Projection(0, 2, 3), st.Collect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	if n != 6 || st.Count != 5 || len(st.Columns) != 3 {
+		t.Fatalf("got %d records, count %d and %d columns", n, st.Count, len(st.Columns))
+	}
+	id, price, extra := st.Columns[0], st.Columns[1], st.Columns[2]
+	if id.Name != "id" || id.Nulls != 0 || id.Numbers != 5 || id.Min != 1 || id.Max != 5 || id.Mean() != 3 || math.Abs(id.StdDev()-math.Sqrt(2.5)) > 1e-12 {
+		t.Errorf("unexpected id stats: %+v", id)
+	}
+	if price.Name != "price" || price.Nulls != 1 || price.Numbers != 3 || price.Min != 2 || price.Max != 9 || price.Mean() != 5 {
+		t.Errorf("unexpected price stats: %+v", price)
+	}
+	if extra.Nulls != 4 || extra.Numbers != 0 || !math.IsNaN(extra.Mean()) || !math.IsNaN(extra.StdDev()) {
+		t.Errorf("unexpected extra stats: %+v", extra)
+	}
+	var b bytes.Buffer
+	if err = st.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "column,count,nulls,numbers,min,max,mean,stddev\nid,5,0,5,1,5,3,1.5811388300841898\nprice,5,1,3,2,9,5,3.605551275463989\n3,5,4,0,,,,\n"
+	if b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// FieldReader and SkipRestOfRecord states
+const (
+	streamOff = iota
+	streamStart
+	streamUnquoted
+	streamQuoted
+	streamEnd
+	skipStart
+	skipUnquoted
+	skipQuoted
+)
+
+// FieldReader advances to the next field and returns a reader streaming its content
+// (doubled quotes are unescaped on the fly) without loading the whole field in memory.
+// The returned reader must be consumed (until io.EOF or an error) before any other call to the Reader.
+// Then EndOfRecord tells if the field has been terminated by a newline.
+// Empty lines and line comments are not skipped.
+func (s *Reader) FieldReader() io.Reader {
+	s.cached = false
+	s.stream = streamStart
+	return &fieldReader{s: s}
+}
+
+type fieldReader struct {
+	s     *Reader
+	chunk []byte // current chunk not yet read
+}
+
+func (f *fieldReader) Read(p []byte) (int, error) {
+	for len(f.chunk) == 0 {
+		if f.s.stream == streamEnd || f.s.stream == streamOff {
+			f.s.stream = streamOff
+			return 0, io.EOF
+		}
+		if !f.s.Scanner.Scan() {
+			f.s.stream = streamOff
+			if err := f.s.Err(); err != nil {
+				return 0, err
+			}
+			return 0, io.EOF
+		}
+		f.chunk = f.s.Bytes()
+	}
+	n := copy(p, f.chunk)
+	f.chunk = f.chunk[n:]
+	return n, nil
+}
+
+// scanChunk returns the next chunk of the streamed field.
+// The last chunk (maybe empty) is returned with the stream state set to streamEnd.
+func (s *Reader) scanChunk(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if s.stream == streamStart {
+		if len(data) == 0 {
+			return 0, nil, nil // request more data (or EOF)
+		}
+		if s.quoted && data[0] == s.quoteChar() {
+			s.stream = streamQuoted
+			data = data[1:]
+			advance = 1
+		} else {
+			s.stream = streamUnquoted
+		}
+	}
+	if s.stream == streamUnquoted {
+		for i, c := range data {
+			if c == s.sep {
+				s.eor = false
+				s.stream = streamEnd
+				return advance + i + 1, data[:i], nil
+			} else if c == '\n' {
+				s.lineno++
+				s.eor = true
+				s.stream = streamEnd
+				if i > 0 && data[i-1] == '\r' {
+					s.crlf++
+					return advance + i + 1, data[:i-1], nil
+				}
+				s.lf++
+				return advance + i + 1, data[:i], nil
+			}
+		}
+		if atEOF {
+			s.eor = true
+			s.stream = streamEnd
+			return advance + len(data), data, nil
+		}
+		n := len(data)
+		if n > 0 && data[n-1] == '\r' { // may be followed by '\n'
+			n--
+		}
+		if n == 0 {
+			return advance, nil, nil
+		}
+		return advance + n, data[:n], nil
+	}
+	// quoted field
+	q := s.quoteChar()
+	i := bytes.IndexByte(data, q)
+	if i < 0 {
+		s.lineno += bytes.Count(data, []byte{'\n'})
+		if atEOF {
+			return 0, nil, fmt.Errorf("non-terminated quoted field at line %d", s.lineno)
+		} else if len(data) == 0 {
+			return advance, nil, nil
+		}
+		return advance + len(data), data, nil
+	} else if i > 0 {
+		s.lineno += bytes.Count(data[:i], []byte{'\n'})
+		return advance + i, data[:i], nil
+	}
+	if len(data) < 2 {
+		if !atEOF {
+			return advance, nil, nil // request more data
+		}
+		s.eor = true
+		s.stream = streamEnd
+		return advance + 1, data[:0], nil
+	}
+	switch c := data[1]; c {
+	case q: // escaped quote
+		return advance + 2, data[:1], nil
+	case s.sep:
+		s.eor = false
+		s.stream = streamEnd
+		return advance + 2, data[:0], nil
+	case '\n':
+		s.lineno++
+		s.lf++
+		s.eor = true
+		s.stream = streamEnd
+		return advance + 2, data[:0], nil
+	case '\r':
+		if len(data) < 3 && !atEOF {
+			return advance, nil, nil // request more data
+		} else if len(data) >= 3 && data[2] == '\n' {
+			s.lineno++
+			s.crlf++
+			s.eor = true
+			s.stream = streamEnd
+			return advance + 3, data[:0], nil
+		}
+	}
+	if s.Lazy {
+		return advance + 1, data[:1], nil
+	}
+	return 0, nil, fmt.Errorf("unescaped %c character at line %d", q, s.lineno)
+}
+
+// SkipRestOfRecord advances to the next record boundary without unescaping the remaining fields of the current record.
+// It does nothing when the current record has already been fully consumed.
+func (s *Reader) SkipRestOfRecord() error {
+	if len(s.pending) > 0 {
+		s.pending = nil
+		s.eor = true
+		return nil
+	} else if s.eor {
+		return nil
+	}
+	s.cached = false
+	s.stream = skipStart
+	s.Scanner.Scan()
+	s.stream = streamOff
+	return s.Err()
+}
+
+// skipRest consumes input until the end of the current record (quote-aware).
+// It returns an empty token once the end is reached.
+func (s *Reader) skipRest(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	q := s.quoteChar()
+	i := 0
+	escaped := -1 // index of the last escaped character (see scanEscapedField)
+	for i < len(data) {
+		c := data[i]
+		if s.stream == skipQuoted {
+			if s.Escape != 0 && c == s.Escape { // escaped quote (or any character, see scanEscapedQuotedField)
+				if i+1 == len(data) && !atEOF {
+					return i, nil, nil // request more data
+				} else if i+1 < len(data) && data[i+1] == '\n' {
+					s.lineno++
+				}
+				i += 2
+				continue
+			} else if c == '\n' {
+				s.lineno++
+			} else if c == q {
+				if i+1 == len(data) && !atEOF {
+					return i, nil, nil // request more data
+				} else if i+1 < len(data) && data[i+1] == q { // escaped quote
+					i += 2
+					continue
+				}
+				s.stream = skipUnquoted // closing quote (maybe the last byte of the input)
+			}
+			i++
+			continue
+		}
+		if s.stream == skipStart {
+			s.stream = skipUnquoted
+			if s.quoted && c == q {
+				s.stream = skipQuoted
+				i++
+				continue
+			}
+		}
+		if s.Escape != 0 && c == s.Escape && i+1 < len(data) { // escaped separator, newline or escape character
+			if data[i+1] == '\n' {
+				s.lineno++
+			}
+			escaped = i + 1
+			i += 2
+			continue
+		} else if s.Escape != 0 && c == s.Escape && !atEOF {
+			return i, nil, nil // request more data
+		} else if c == s.sep {
+			s.stream = skipStart
+		} else if c == '\r' && i+1 == len(data) && !atEOF {
+			return i, nil, nil // request more data (to count \r\n as one line ending)
+		} else if c == '\n' {
+			s.lineno++
+			if i > 0 && data[i-1] == '\r' && escaped != i-1 {
+				s.crlf++
+			} else {
+				s.lf++
+			}
+			s.eor = true
+			s.stream = streamOff
+			return i + 1, data[:0], nil
+		}
+		i++
+	}
+	if !atEOF {
+		return len(data), nil, nil
+	}
+	if s.stream == skipQuoted {
+		return 0, nil, fmt.Errorf("non-terminated quoted field at line %d", s.lineno)
+	}
+	s.eor = true
+	s.stream = streamOff
+	return len(data), data[:0], nil
+}
+
+// truncate consumes the rest of an oversized field, keeping its first MaxFieldSize bytes (see TruncateFields).
+// It returns them once the end of the field is reached.
+func (s *Reader) truncate(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	for {
+		a, chunk, err := s.scanChunk(data[advance:], atEOF)
+		if err != nil {
+			return 0, nil, err
+		}
+		advance += a
+		if n := s.MaxFieldSize - len(s.trunc); n > 0 {
+			if len(chunk) > n {
+				chunk = chunk[:n]
+			}
+			s.trunc = append(s.trunc, chunk...)
+		}
+		if s.stream == streamEnd {
+			s.stream = streamOff
+			s.truncating = false
+			return advance, s.trunc, nil
+		} else if a == 0 && chunk == nil {
+			return advance, nil, nil // request more data
+		}
+	}
+}
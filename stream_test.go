@@ -0,0 +1,166 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestFieldReader(t *testing.T) {
+	huge := strings.Repeat("ab\"\"c,\r\n", 100000)
+	input := "1,\"" + huge + "\",x\r\n" + strings.Repeat("y", 100000) + "\n\"z\""
+	r := DefaultReader(strings.NewReader(input))
+	r.Buffer(make([]byte, 64), 64)
+	var fields []string
+	var eors []bool
+	for {
+		b, err := ioutil.ReadAll(r.FieldReader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fields) == 5 {
+			break
+		}
+		fields = append(fields, string(b))
+		eors = append(eors, r.EndOfRecord())
+	}
+	want := []string{"1", strings.Replace(huge, `""`, `"`, -1), "x", strings.Repeat("y", 100000), "z"}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("#%d: got %.20q (%d); want %.20q (%d)", i, fields[i], len(fields[i]), want[i], len(want[i]))
+		}
+	}
+	if !reflect.DeepEqual(eors, []bool{false, false, true, true, true}) {
+		t.Errorf("unexpected end of records: %v", eors)
+	}
+	if n := r.LineNumber(); n != 100003 {
+		t.Errorf("got line %d; want %d", n, 100003)
+	}
+}
+
+func TestFieldReaderMixed(t *testing.T) {
+	r := DefaultReader(strings.NewReader("a,\"b\"\"\",c\nd\n"))
+	if !r.Scan() || r.Text() != "a" {
+		t.Fatalf("unexpected field: %q", r.Text())
+	}
+	b := &bytes.Buffer{}
+	if _, err := io.Copy(b, r.FieldReader()); err != nil {
+		t.Fatal(err)
+	} else if b.String() != `b"` {
+		t.Errorf("got %q; want %q", b.String(), `b"`)
+	}
+	var values []string
+	for r.Scan() {
+		values = append(values, r.Text())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, []string{"c", "d"}) {
+		t.Errorf("got %q; want %q", values, []string{"c", "d"})
+	}
+}
+
+func TestFieldReaderError(t *testing.T) {
+	for _, input := range []string{`"a"b`, `"ab`} {
+		r := DefaultReader(strings.NewReader(input))
+		if _, err := ioutil.ReadAll(r.FieldReader()); err == nil {
+			t.Errorf("%q: error expected", input)
+		}
+	}
+}
+
+func TestSkipRestOfRecord(t *testing.T) {
+	input := "a,\"b,\"\"\n\"\"\",c\nd,e\"f\n\"g\"\r\n" + strings.Repeat("h,", 10000) + "\ni"
+	r := DefaultReader(strings.NewReader(input))
+	r.Lazy = true
+	var firsts []string
+	for r.Scan() {
+		firsts = append(firsts, r.Text())
+		if err := r.SkipRestOfRecord(); err != nil {
+			t.Fatal(err)
+		}
+		if !r.EndOfRecord() {
+			t.Error("end of record expected")
+		}
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "d", "g", "h", "i"}; !reflect.DeepEqual(firsts, want) {
+		t.Errorf("got %q; want %q", firsts, want)
+	}
+	if n := r.LineNumber(); n != 6 {
+		t.Errorf("got line %d; want %d", n, 6)
+	}
+
+	r = DefaultReader(strings.NewReader("a,\"b"))
+	r.Scan()
+	if err := r.SkipRestOfRecord(); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestSkipRestOfRecordAtEOF(t *testing.T) {
+	for _, input := range []string{`a,"b"`, `a,"b""c"`, `a,"b",c`} {
+		r := DefaultReader(strings.NewReader(input))
+		if err := r.SkipRecords(1); err != nil {
+			t.Errorf("%q: unexpected error: %v", input, err)
+		} else if r.Scan() {
+			t.Errorf("%q: got %q; want EOF", input, r.Text())
+		}
+	}
+}
+
+// chunkReader returns its chunks one by one.
+type chunkReader []string
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(*c) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, (*c)[0])
+	if (*c)[0] = (*c)[0][n:]; len((*c)[0]) == 0 {
+		*c = (*c)[1:]
+	}
+	return n, nil
+}
+
+func TestSkipRestOfRecordCRLF(t *testing.T) {
+	r := DefaultReader(&chunkReader{"a,b\r", "\nc,d\r\n"})
+	for r.Scan() {
+		if err := r.SkipRestOfRecord(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if le := r.LineEndings(); le != (LineEndings{CRLF: 2}) {
+		t.Errorf("got %+v; want %+v", le, LineEndings{CRLF: 2})
+	}
+}
+
+func TestSkipRestOfRecordEscape(t *testing.T) {
+	for _, input := range []string{"a,b\\\nc,d\ne,f\n", "a,b\\,c\\\\\ne,f\n", "a,\"x\\\"y,\nz\"\ne,f\n"} {
+		r := DefaultReader(strings.NewReader(input))
+		r.Escape = '\\'
+		if !r.Scan() {
+			t.Fatalf("%q: %v", input, r.Err())
+		} else if err := r.SkipRestOfRecord(); err != nil {
+			t.Fatalf("%q: %v", input, err)
+		}
+		if record, err := r.Record(); err != nil || !reflect.DeepEqual(record, []string{"e", "f"}) {
+			t.Errorf("%q: got %q (%v); want %q", input, record, err, []string{"e", "f"})
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package yacr
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// TypedWriterOptions controls NewTypedWriter behaviour.
+type TypedWriterOptions struct {
+	Dialect    Dialect // comma separated and quoted values when Sep is 0
+	SkipHeader bool    // true to not write the header (field names, see Writer.WriteStruct) before the first record
+}
+
+// TypedWriter writes values of the struct type T (or pointer to struct) as records.
+// The fields encoding is planned once by NewTypedWriter:
+// strings, numbers, booleans, []byte, time.Time, encoding.TextMarshaler, fmt.Stringer and pointers to them are supported,
+// nil pointers being written as nulls (see Writer.Null and Writer.QuoteEmpty).
+// Times are formatted with the layout specified by the "layout=" tag option (like `csv:"day,layout=2006-01-02"`)
+// or with Writer.Time.
+type TypedWriter[T any] struct {
+	w      *Writer
+	ptr    bool // T is a pointer to struct
+	fields []structField
+	plans  []typedPlan
+	header bool // true once the header has been written
+}
+
+// typedPlan writes one field value.
+type typedPlan func(w *Writer, v reflect.Value) bool
+
+// NewTypedWriter returns a TypedWriter writing to w.
+// It fails when T is not a struct (or a pointer to struct) or when a field type is not supported.
+func NewTypedWriter[T any](w io.Writer, opts *TypedWriterOptions) (*TypedWriter[T], error) {
+	if opts == nil {
+		opts = &TypedWriterOptions{}
+	}
+	d := opts.Dialect
+	if d.Sep == 0 {
+		d.Sep, d.Quoted = ',', true
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	tw := &TypedWriter[T]{w: NewWriterDialect(w, d), header: opts.SkipHeader}
+	if t.Kind() == reflect.Ptr {
+		tw.ptr, t = true, t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("yacr: unsupported type: %s (struct expected)", t)
+	}
+	tw.fields = fieldsOf(t)
+	tw.plans = make([]typedPlan, len(tw.fields))
+	for i, f := range tw.fields {
+		if tw.plans[i] = newTypedPlan(t.Field(f.index).Type, f.layout); tw.plans[i] == nil {
+			return nil, fmt.Errorf("yacr: unsupported type: %s for field %s", t.Field(f.index).Type, t.Field(f.index).Name)
+		}
+	}
+	return tw, nil
+}
+
+// Writer returns the underlying Writer (to customize the output format).
+func (tw *TypedWriter[T]) Writer() *Writer {
+	return tw.w
+}
+
+// Write writes v as one record (preceded by the header on the first call).
+// A nil pointer is skipped.
+func (tw *TypedWriter[T]) Write(v T) error {
+	if !tw.header {
+		tw.header = true
+		for _, f := range tw.fields {
+			if !tw.w.WriteString(f.name) {
+				return tw.w.err
+			}
+		}
+		tw.w.EndOfRecord()
+	}
+	rv := reflect.ValueOf(&v).Elem()
+	if tw.ptr {
+		if rv.IsNil() {
+			return tw.w.err
+		}
+		rv = rv.Elem()
+	}
+	for i, f := range tw.fields {
+		if !tw.plans[i](tw.w, rv.Field(f.index)) {
+			return tw.w.err
+		}
+	}
+	tw.w.EndOfRecord()
+	return tw.w.err
+}
+
+// WriteAll writes all values and flushes the output.
+func (tw *TypedWriter[T]) WriteAll(values []T) error {
+	for _, v := range values {
+		if err := tw.Write(v); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// Flush ensures the output is flushed.
+func (tw *TypedWriter[T]) Flush() error {
+	tw.w.Flush()
+	return tw.w.err
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// newTypedPlan builds the plan encoding values of type t (nil when unsupported).
+func newTypedPlan(t reflect.Type, layout string) typedPlan {
+	switch {
+	case t.Kind() == reflect.Ptr:
+		elem := newTypedPlan(t.Elem(), layout)
+		if elem == nil {
+			return nil
+		}
+		return func(w *Writer, v reflect.Value) bool {
+			if v.IsNil() {
+				return w.WriteNull()
+			}
+			return elem(w, v.Elem())
+		}
+	case t == timeType:
+		if layout != "" {
+			return func(w *Writer, v reflect.Value) bool {
+				w.nb = v.Interface().(time.Time).AppendFormat(w.nb[:0], layout)
+				return w.Write(w.nb)
+			}
+		}
+		return func(w *Writer, v reflect.Value) bool {
+			return w.WriteValue(v.Interface().(time.Time))
+		}
+	case t.Implements(textMarshalerType):
+		return func(w *Writer, v reflect.Value) bool {
+			return w.writeText(v.Interface().(encoding.TextMarshaler))
+		}
+	case t.Implements(stringerType):
+		return func(w *Writer, v reflect.Value) bool {
+			return w.WriteString(v.Interface().(fmt.Stringer).String())
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return func(w *Writer, v reflect.Value) bool {
+			return w.WriteString(v.String())
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(w *Writer, v reflect.Value) bool {
+			w.nb = strconv.AppendInt(w.nb[:0], v.Int(), 10)
+			return w.Write(w.nb)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(w *Writer, v reflect.Value) bool {
+			w.nb = strconv.AppendUint(w.nb[:0], v.Uint(), 10)
+			return w.Write(w.nb)
+		}
+	case reflect.Bool:
+		return func(w *Writer, v reflect.Value) bool {
+			return w.WriteString(w.Bools.format(v.Bool()))
+		}
+	case reflect.Float32, reflect.Float64:
+		bits := t.Bits()
+		return func(w *Writer, v reflect.Value) bool {
+			return w.Write(w.appendFloat(v.Float(), bits))
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return nil
+		}
+		return func(w *Writer, v reflect.Value) bool {
+			return w.Write(v.Bytes())
+		}
+	}
+	return nil
+}
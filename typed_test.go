@@ -0,0 +1,57 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package yacr_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestTypedWriter(t *testing.T) {
+	type event struct {
+		ID     uint64
+		Name   string    `csv:"name"`
+		Day    time.Time `csv:"day,layout=2006-01-02"`
+		At     time.Time
+		Score  *float64
+		IP     net.IP
+		OK     bool
+		Ignore int `csv:"-"`
+	}
+	score := 1.5
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := &bytes.Buffer{}
+	tw, err := NewTypedWriter[*event](b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tw.WriteAll([]*event{
+		{ID: 1, Name: "a,b", Day: at, At: at, Score: &score, IP: net.IPv4(127, 0, 0, 1), OK: true},
+		nil,
+		{ID: 2, Day: at, At: at, IP: net.IP{}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := "ID,name,day,At,Score,IP,OK\n" +
+		"1,\"a,b\",2024-03-01,2024-03-01T12:00:00Z,1.5,127.0.0.1,true\n" +
+		"2,,2024-03-01,2024-03-01T12:00:00Z,,,false\n"
+	if b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+
+	if _, err = NewTypedWriter[int](b, nil); err == nil {
+		t.Error("error expected for a non-struct type")
+	}
+	if _, err = NewTypedWriter[struct{ C chan int }](b, nil); err == nil {
+		t.Error("error expected for an unsupported field type")
+	}
+}
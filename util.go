@@ -16,6 +16,7 @@ import (
 type zReadCloser struct {
 	f  *os.File
 	rd io.ReadCloser
+	n  int64 // number of compressed bytes read from f (see Reader.Progress)
 }
 
 // Zopen transparently opens gzip/bzip files (based on their extension).
@@ -24,26 +25,44 @@ func Zopen(filepath string) (io.ReadCloser, error) {
 	if err != nil {
 		return nil, err
 	}
-	var rd io.ReadCloser
+	z := &zReadCloser{f: f}
 	// TODO zip
 	ext := path.Ext(f.Name())
 	if ext == ".gz" {
-		rd, err = gzip.NewReader(f)
+		z.rd, err = gzip.NewReader(z.compressed())
 		if err != nil {
 			_ = f.Close()
 			return nil, err
 		}
 	} else if ext == ".bz2" {
-		rd = ioutil.NopCloser(bzip2.NewReader(f))
+		z.rd = ioutil.NopCloser(bzip2.NewReader(z.compressed()))
 	} else {
-		rd = f
+		z.rd = f
 	}
-	return &zReadCloser{f, rd}, nil
+	return z, nil
+}
+
+// compressed returns a reader of f counting the bytes read.
+func (z *zReadCloser) compressed() io.Reader {
+	return readerFunc(func(b []byte) (int, error) {
+		n, err := z.f.Read(b)
+		z.n += int64(n)
+		return n, err
+	})
+}
+
+type readerFunc func(b []byte) (int, error)
+
+func (f readerFunc) Read(b []byte) (int, error) {
+	return f(b)
 }
 func (z *zReadCloser) Read(b []byte) (n int, err error) {
 	return z.rd.Read(b)
 }
 func (z *zReadCloser) Close() (err error) {
+	if z.rd == io.ReadCloser(z.f) {
+		return z.f.Close()
+	}
 	err = z.rd.Close()
 	if err != nil {
 		_ = z.f.Close()
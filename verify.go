@@ -0,0 +1,116 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// Divergence describes the first difference between yacr and encoding/csv parsing of the same input.
+type Divergence struct {
+	Record int      // record number (first is 1)
+	Field  int      // field number (first is 1), 0 when the whole record is concerned
+	Reason string   // description of the difference
+	Yacr   []string // record returned by yacr (nil on error or at the end of the input)
+	Std    []string // record returned by encoding/csv (nil on error or at the end of the input)
+}
+
+func (d *Divergence) String() string {
+	if d.Field == 0 {
+		return fmt.Sprintf("record %d: %s", d.Record, d.Reason)
+	}
+	return fmt.Sprintf("record %d, field %d: %s", d.Record, d.Field, d.Reason)
+}
+
+// VerifyAgainstStd parses r with a DefaultReader and with encoding/csv (without checking the number of fields)
+// and returns the first divergence (nil when records are the same, or when both parsers fail on the same record).
+// The input is read once: only the data not yet parsed by both parsers is kept in memory.
+// The returned error reports a failure to read r.
+func VerifyAgainstStd(r io.Reader) (*Divergence, error) {
+	src := &sharedSource{r: r}
+	yr := DefaultReader(&sourceCursor{src: src, id: 0})
+	sr := csv.NewReader(&sourceCursor{src: src, id: 1})
+	sr.FieldsPerRecord = -1
+	for n := 1; ; n++ {
+		y, yerr := yr.Record()
+		s, serr := sr.Read()
+		if src.err != nil && src.err != io.EOF {
+			return nil, src.err
+		}
+		d := &Divergence{Record: n, Yacr: y, Std: s}
+		switch {
+		case yerr == io.EOF && serr == io.EOF:
+			return nil, nil
+		case yerr != nil && serr != nil && yerr != io.EOF && serr != io.EOF:
+			return nil, nil // both reject the input
+		case yerr == io.EOF:
+			d.Reason = "extra record parsed by encoding/csv"
+		case serr == io.EOF:
+			d.Reason = "extra record parsed by yacr"
+		case yerr != nil:
+			d.Reason = "yacr error: " + yerr.Error()
+		case serr != nil:
+			d.Reason = "encoding/csv error: " + serr.Error()
+		default:
+			for i := 0; i < len(y) && i < len(s); i++ {
+				if y[i] != s[i] {
+					d.Field = i + 1
+					d.Reason = fmt.Sprintf("got %q with yacr and %q with encoding/csv", y[i], s[i])
+					return d, nil
+				}
+			}
+			if len(y) == len(s) {
+				continue
+			}
+			d.Reason = fmt.Sprintf("got %d field(s) with yacr and %d with encoding/csv", len(y), len(s))
+		}
+		if yerr != nil {
+			d.Yacr = nil
+		}
+		if serr != nil {
+			d.Std = nil
+		}
+		return d, nil
+	}
+}
+
+// sharedSource lets two cursors read the same input, keeping only the data not yet read by both.
+type sharedSource struct {
+	r     io.Reader
+	err   error
+	buf   []byte   // data read from r not yet consumed by both cursors
+	start int64    // offset of buf in the input
+	pos   [2]int64 // offset of each cursor
+}
+
+type sourceCursor struct {
+	src *sharedSource
+	id  int
+}
+
+func (c *sourceCursor) Read(p []byte) (int, error) {
+	s := c.src
+	for s.pos[c.id] == s.start+int64(len(s.buf)) { // no buffered data for this cursor
+		if s.err != nil {
+			return 0, s.err
+		}
+		var chunk [4096]byte
+		n, err := s.r.Read(chunk[:])
+		s.buf = append(s.buf, chunk[:n]...)
+		s.err = err
+	}
+	n := copy(p, s.buf[s.pos[c.id]-s.start:])
+	s.pos[c.id] += int64(n)
+	if min := s.pos[1-c.id]; min > s.start { // drop data consumed by both cursors
+		if s.pos[c.id] < min {
+			min = s.pos[c.id]
+		}
+		s.buf = s.buf[:copy(s.buf, s.buf[min-s.start:])]
+		s.start = min
+	}
+	return n, nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestVerifyAgainstStd(t *testing.T) {
+	var tests = []struct {
+		Input  string
+		Record int // 0 when no divergence
+		Field  int
+		Reason string // expected prefix of the reason
+	}{
+		{"a,b\n\n\"c\"\"d\",\"e\nf\"\n", 0, 0, ""},
+		{strings.Repeat("abc,\"d,e\",f\n", 2000), 0, 0, ""},
+		{"a,b\nc,d,\n", 0, 0, ""},
+		{"a,b\n\"c\"d,e\n", 0, 0, ""}, // both reject
+		{"a,b\nc,d\na\"b,c\n", 3, 0, "encoding/csv error: "},
+		{"a,b\n\"x\r\ny\",z\n", 2, 1, `got "x\r\ny" with yacr and "x\ny" with encoding/csv`},
+	}
+	for _, test := range tests {
+		d, err := VerifyAgainstStd(strings.NewReader(test.Input))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.Input, err)
+		} else if test.Record == 0 {
+			if d != nil {
+				t.Errorf("%q: unexpected divergence: %s", test.Input, d)
+			}
+		} else if d == nil {
+			t.Errorf("%q: got no divergence; want record %d, field %d", test.Input, test.Record, test.Field)
+		} else if d.Record != test.Record || d.Field != test.Field || !strings.HasPrefix(d.Reason, test.Reason) {
+			t.Errorf("%q: got %s; want record %d, field %d: %s", test.Input, d, test.Record, test.Field, test.Reason)
+		}
+	}
+}
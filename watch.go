@@ -0,0 +1,107 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WatchOptions controls WatchDir behaviour.
+type WatchOptions struct {
+	MultiFileOptions
+	Pattern      string        // only files whose name matches this pattern are read (see filepath.Match), all files when empty
+	Interval     time.Duration // delay between two directory scans (1s by default)
+	SkipExisting bool          // ignore files present when WatchDir is called
+}
+
+// WatchDir returns a multi-file Reader (see NewMultiFileReader) streaming records from files appearing in dir,
+// in arrival order (modification time), each file being read once.
+// Files should be moved into dir once complete; hidden files (starting with a dot) are ignored.
+// Scan blocks while waiting for new files, until ctx is done (Err then returns ctx.Err()).
+func WatchDir(ctx context.Context, dir string, opts *WatchOptions) *Reader {
+	if opts == nil {
+		opts = &WatchOptions{}
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	s := NewMultiFileReader(nil, &opts.MultiFileOptions)
+	seen := make(map[string]bool) // files already queued (and still present)
+	var queue []os.FileInfo
+	scan := func() error {
+		fis, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		present := make(map[string]bool, len(fis))
+		var added []os.FileInfo
+		for _, fi := range fis {
+			name := fi.Name()
+			if !fi.Mode().IsRegular() || strings.HasPrefix(name, ".") {
+				continue
+			} else if opts.Pattern != "" {
+				if ok, err := filepath.Match(opts.Pattern, name); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
+			}
+			present[name] = true
+			if !seen[name] {
+				seen[name] = true
+				added = append(added, fi)
+			}
+		}
+		for name := range seen { // a file deleted then dropped again is read again
+			if !present[name] {
+				delete(seen, name)
+			}
+		}
+		sort.Slice(added, func(i, j int) bool {
+			if ti, tj := added[i].ModTime(), added[j].ModTime(); !ti.Equal(tj) {
+				return ti.Before(tj)
+			}
+			return added[i].Name() < added[j].Name()
+		})
+		queue = append(queue, added...)
+		return nil
+	}
+	if opts.SkipExisting {
+		if err := scan(); err != nil {
+			s.srcErr = err
+		}
+		queue = nil
+	}
+	s.next = func() (string, io.ReadCloser, error) {
+		for len(queue) == 0 {
+			if err := ctx.Err(); err != nil {
+				return "", nil, err
+			} else if err = scan(); err != nil {
+				return "", nil, err
+			} else if len(queue) > 0 {
+				break
+			}
+			t := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+			case <-t.C:
+			}
+		}
+		path := filepath.Join(dir, queue[0].Name())
+		queue = queue[1:]
+		rc, err := Zopen(path)
+		return path, rc, err
+	}
+	return s
+}
@@ -0,0 +1,60 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/gwenn/yacr"
+)
+
+func TestWatchDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"old.csv": "id\n0\n"})
+	defer os.RemoveAll(dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := WatchDir(ctx, dir, &WatchOptions{
+		MultiFileOptions: MultiFileOptions{Header: true},
+		Pattern:          "*.csv",
+		Interval:         10 * time.Millisecond,
+		SkipExisting:     true,
+	})
+	defer r.Close()
+	go func() {
+		for i, content := range []string{"id\n1\n", "id\n2\n3\n"} {
+			time.Sleep(20 * time.Millisecond)
+			tmp := filepath.Join(dir, ".tmp")
+			if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+				t.Error(err)
+			} else if err = os.Rename(tmp, filepath.Join(dir, string(rune('a'+i))+".csv")); err != nil {
+				t.Error(err)
+			}
+		}
+		ioutil.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("x\n"), 0644)
+	}()
+	var got [][]string
+	for len(got) < 4 {
+		values, err := r.Record()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, values)
+	}
+	want := [][]string{{"id"}, {"1"}, {"2"}, {"3"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	cancel()
+	if values, err := r.Record(); err == nil || !errors.Is(r.Err(), context.Canceled) {
+		t.Errorf("got %q (%v); want %v", values, err, context.Canceled)
+	}
+}
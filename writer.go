@@ -6,13 +6,16 @@ package yacr
 
 import (
 	"bufio"
+	"bytes"
+	"database/sql/driver"
 	"encoding"
 	"errors"
 	"fmt"
 	"io"
 	"reflect"
 	"strconv"
-	"unsafe"
+	"strings"
+	"time"
 )
 
 // Writer provides an interface for writing CSV data
@@ -21,14 +24,94 @@ import (
 // The EndOfRecord method tells when a line break is inserted.
 type Writer struct {
 	b      *bufio.Writer
-	sep    byte                 // values separator
-	quoted bool                 // specify if values should be quoted (when they contain a separator, a double-quote or a newline)
-	sor    bool                 // true at start of record
-	err    error                // sticky error.
-	bs     []byte               // byte slice used to write string with minimal/no alloc/copy
-	hb     *reflect.SliceHeader // header of bs
+	sep    byte   // values separator
+	quoted bool   // specify if values should be quoted (when they contain a separator, a double-quote or a newline)
+	sor    bool   // true at start of record
+	col    int    // index of the next field in the current record
+	err    error  // sticky error.
+	nb     []byte // scratch buffer used to format numbers
+	rec    []byte // current record (written at once by EndOfRecord)
+	eor    func() // called once a record has been written by EndOfRecord (see FileWriter)
 
-	UseCRLF bool // True to use \r\n as the line terminator
+	header  []string       // see SetHeader
+	columns map[string]int // index by header
+	values  []interface{}  // current record values placed according to the header
+
+	encoders []func([]byte) ([]byte, error) // by column index (see SetColumnEncoder)
+	ev       []byte                         // scratch buffer holding a copy of the value given to an encoder
+
+	widths []int // widths of the columns of a fixed-width Writer (see NewFixedWriter)
+
+	UseCRLF     bool    // True to use \r\n as the line terminator
+	Quoting     Quoting // In quoted mode, policy telling which values are quoted (QuoteMinimal by default)
+	QuoteSpaces bool    // In quoted mode, true to quote values beginning or ending with a space or a tab
+	QuoteEmpty  bool    // In quoted mode, true to quote empty values (to distinguish them from nulls)
+	Null        string  // Token written (never quoted) for null values by WriteNull and WriteValue (nil, nil pointers and null driver.Valuer like sql.NullString) (empty by default)
+	DropUnknown bool    // true to ignore values of columns missing from the header (see SetHeader) instead of failing
+	Quote       byte    // In quoted mode, character enclosing values (escaped by doubling it). Double quote when 0.
+	SepString   string  // When specified, (multi-byte) separator written instead of sep, like "||" (Escape is not supported in unquoted mode)
+	// In unquoted mode, Escape is the character used to escape a separator, a newline or itself (instead of failing).
+	// In quoted mode, it is used to escape a quote, itself, \n and \r (as \", \\, \n and \r) instead of doubling quotes.
+	Escape byte // Disabled when 0.
+
+	Float        *NumberFormat   // Default format of floats written by WriteValue ('f' with the smallest precision by default)
+	ColumnFloats []*NumberFormat // Format of floats by column index (nil entries fall back to Float)
+	Time         *TimeFormat     // Default format of times written by WriteValue (RFC3339Nano by default)
+	ColumnTimes  []*TimeFormat   // Format of times by column index (nil entries fall back to Time)
+	Bools        *BoolTokens     // Tokens written for booleans (instead of "true"/"false")
+
+	Align []Alignment // In fixed-width mode, alignment by column (missing ones are AlignDefault which means left)
+}
+
+// Quoting is the policy used by Writer (in quoted mode) to choose which values are quoted.
+type Quoting int
+
+// Quoting policies
+const (
+	QuoteMinimal    Quoting = iota // only values containing a separator, a quote or a newline are quoted
+	QuoteAll                       // all values are quoted
+	QuoteNonNumeric                // all values except numbers are quoted
+)
+
+var quotingNames = []string{"minimal", "all", "nonnumeric"}
+
+func (q Quoting) String() string {
+	if q < 0 || int(q) >= len(quotingNames) {
+		return "Quoting(" + strconv.Itoa(int(q)) + ")"
+	}
+	return quotingNames[q]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (q Quoting) MarshalText() ([]byte, error) {
+	if q < 0 || int(q) >= len(quotingNames) {
+		return nil, fmt.Errorf("yacr: invalid quoting policy: %d", int(q))
+	}
+	return []byte(quotingNames[q]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (q *Quoting) UnmarshalText(text []byte) error {
+	for i, name := range quotingNames {
+		if name == string(text) {
+			*q = Quoting(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("yacr: unknown quoting policy: %s", text)
+}
+
+// NumberFormat tells how floats are formatted (see strconv.FormatFloat).
+type NumberFormat struct {
+	Verb       byte // 'f', 'g', 'e', ... ('f' when 0)
+	Prec       int  // number of digits (-1 for the smallest number of digits necessary)
+	DecimalSep byte // decimal separator ('.' when 0)
+}
+
+// TimeFormat tells how times are formatted.
+type TimeFormat struct {
+	Layout   string         // see time.Format (time.RFC3339Nano when empty)
+	Location *time.Location // times are converted to this location before formatting (unless nil)
 }
 
 // DefaultWriter creates a "standard" CSV writer (separator is comma and quoted mode active)
@@ -38,13 +121,12 @@ func DefaultWriter(wr io.Writer) *Writer {
 
 // NewWriter returns a new CSV writer.
 func NewWriter(w io.Writer, sep byte, quoted bool) *Writer {
-	wr := &Writer{b: bufio.NewWriter(w), sep: sep, quoted: quoted, sor: true}
-	wr.hb = (*reflect.SliceHeader)(unsafe.Pointer(&wr.bs))
-	return wr
+	return &Writer{b: bufio.NewWriter(w), sep: sep, quoted: quoted, sor: true}
 }
 
-// WriteRecord ensures that values are quoted when needed.
-// It's like fmt.Println.
+// WriteRecord ensures that values are quoted when needed (see WriteValue) and terminates the record.
+// It's like fmt.Println and the dual of Reader.ScanRecord.
+// It returns false on error (reported by Err).
 func (w *Writer) WriteRecord(values ...interface{}) bool {
 	for _, v := range values {
 		if !w.WriteValue(v) {
@@ -56,11 +138,17 @@ func (w *Writer) WriteRecord(values ...interface{}) bool {
 }
 
 // WriteValue ensures that value is quoted when needed.
-// Value's type/kind is used to encode value to text.
+// Value's type/kind is used to encode value to text:
+// encoding.TextMarshaler and fmt.Stringer implementations are preferred to the underlying kind.
+// When value cannot be encoded, the error is reported by Err and an empty field is written
+// (so that the following fields keep their position).
 func (w *Writer) WriteValue(value interface{}) bool {
+	if isNilPtr(value) { // before calling methods with a value receiver
+		return w.WriteNull()
+	}
 	switch value := value.(type) {
 	case nil:
-		return w.Write([]byte{})
+		return w.WriteNull()
 	case string:
 		return w.WriteString(value)
 	case int:
@@ -70,26 +158,46 @@ func (w *Writer) WriteValue(value interface{}) bool {
 	case int64:
 		return w.WriteString(strconv.FormatInt(value, 10))
 	case bool:
-		return w.WriteString(strconv.FormatBool(value))
+		return w.WriteString(w.Bools.format(value))
 	case float32:
-		return w.WriteString(strconv.FormatFloat(float64(value), 'f', -1, 32))
+		return w.Write(w.appendFloat(float64(value), 32))
 	case float64:
-		return w.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+		return w.Write(w.appendFloat(value, 64))
 	case []byte:
 		return w.Write(value)
-	case encoding.TextMarshaler: // time.Time
-		if text, err := value.MarshalText(); err != nil {
+	case time.Time:
+		if tf := w.timeFormat(); tf != nil {
+			return w.Write(w.appendTime(value, tf))
+		}
+		return w.writeText(value)
+	case encoding.TextMarshaler:
+		return w.writeText(value)
+	case driver.Valuer:
+		v, err := value.Value()
+		if err != nil {
 			w.setErr(err)
-			w.Write([]byte{}) // TODO Validate: write an empty field
+			w.Write([]byte{})
 			return false
-		} else {
-			return w.Write(text) // please, ignore golint
 		}
+		return w.WriteValue(v)
+	case fmt.Stringer:
+		return w.WriteString(value.String())
 	default:
 		return w.writeReflect(value)
 	}
 }
 
+// writeText writes value marshaled as text.
+func (w *Writer) writeText(value encoding.TextMarshaler) bool {
+	if text, err := value.MarshalText(); err != nil {
+		w.setErr(err)
+		w.Write([]byte{})
+		return false
+	} else {
+		return w.Write(text) // please, ignore golint
+	}
+}
+
 // WriteReflect ensures that value is quoted when needed.
 // Value's (reflect) Kind is used to encode value to text.
 func (w *Writer) writeReflect(value interface{}) bool {
@@ -102,24 +210,30 @@ func (w *Writer) writeReflect(value interface{}) bool {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		return w.WriteString(strconv.FormatUint(v.Uint(), 10))
 	case reflect.Bool:
-		return w.WriteString(strconv.FormatBool(v.Bool()))
+		return w.WriteString(w.Bools.format(v.Bool()))
 	case reflect.Float32, reflect.Float64:
-		return w.WriteString(strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()))
+		return w.Write(w.appendFloat(v.Float(), v.Type().Bits()))
+	case reflect.Ptr:
+		if v.IsNil() {
+			return w.WriteNull()
+		}
+		return w.WriteValue(v.Elem().Interface())
 	default:
 		w.setErr(fmt.Errorf("unsupported type: %T, %v", value, value))
-		w.Write([]byte{}) // TODO Validate: write an empty field
+		w.Write([]byte{})
 		return false
 	}
 }
 
+// isNilPtr tells if value is a nil pointer.
+func isNilPtr(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
 // WriteString ensures that value is quoted when needed.
 func (w *Writer) WriteString(value string) bool {
-	// To avoid making a copy...
-	hs := (*reflect.StringHeader)(unsafe.Pointer(&value))
-	w.hb.Data = hs.Data
-	w.hb.Len = hs.Len
-	w.hb.Cap = hs.Len
-	return w.Write(w.bs)
+	return w.Write(stringBytes(value)) // To avoid making a copy...
 }
 
 var (
@@ -134,35 +248,73 @@ func (w *Writer) Write(value []byte) bool {
 	if w.err != nil {
 		return false
 	}
+	if w.col < len(w.encoders) && w.encoders[w.col] != nil {
+		var err error
+		w.ev = append(w.ev[:0], value...) // value may be a read-only string (see WriteString)
+		if value, err = w.encoders[w.col](w.ev); err != nil {
+			w.setErr(fmt.Errorf("yacr.Writer: column %d: %w", w.col, err))
+			return false
+		}
+	}
+	if w.widths != nil {
+		return w.writeFixed(value)
+	}
 	if !w.sor {
-		w.setErr(w.b.WriteByte(w.sep))
+		w.appendSep()
+	}
+	sep := w.sep
+	if w.SepString != "" {
+		sep = '\n' // SepString occurrences are checked as a whole (see containsSep)
 	}
 	// In quoted mode, value is enclosed between quotes if it contains sep, quote or \n.
-	if w.quoted {
+	if w.quoted && w.Escape != 0 {
+		w.writeEscaped(value)
+	} else if w.quoted {
+		q := w.Quote
+		if q == 0 {
+			q = '"'
+		}
+		opened := w.forceQuotes(value)
+		if opened {
+			w.rec = append(w.rec, q)
+		}
 		last := 0
 		for i, c := range value {
 			switch c {
-			case '"', '\r', '\n', w.sep:
+			case q, '\r', '\n', sep:
 			default:
 				continue
 			}
-			if last == 0 {
-				w.setErr(w.b.WriteByte('"'))
+			if !opened {
+				w.rec = append(w.rec, q)
+				opened = true
 			}
-			if _, err := w.b.Write(value[last : i+1]); err != nil {
-				w.setErr(err)
-			}
-			if c == '"' {
-				w.setErr(w.b.WriteByte(c)) // escaped with another double quote
+			w.rec = append(w.rec, value[last:i+1]...)
+			if c == q {
+				w.rec = append(w.rec, c) // escaped with another quote
 			}
 			last = i + 1
 		}
-		if _, err := w.b.Write(value[last:]); err != nil {
-			w.setErr(err)
+		w.rec = append(w.rec, value[last:]...)
+		if opened {
+			w.rec = append(w.rec, q)
 		}
-		if last != 0 {
-			w.setErr(w.b.WriteByte('"'))
+	} else if w.SepString != "" && w.containsSep(value) {
+		w.setErr(ErrSeparator)
+		return false
+	} else if w.Escape != 0 {
+		last := 0
+		for i, c := range value {
+			switch c {
+			case '\r', '\n', sep, w.Escape:
+			default:
+				continue
+			}
+			w.rec = append(w.rec, value[last:i]...)
+			w.rec = append(w.rec, w.Escape)
+			last = i
 		}
+		w.rec = append(w.rec, value[last:]...)
 	} else {
 		// check that value does not contain sep or \n
 		for _, c := range value {
@@ -170,35 +322,216 @@ func (w *Writer) Write(value []byte) bool {
 			case '\n':
 				w.setErr(ErrNewLine)
 				return false
-			case w.sep:
+			case sep:
 				w.setErr(ErrSeparator)
 				return false
 			default:
 				continue
 			}
 		}
-		if _, err := w.b.Write(value); err != nil {
-			w.setErr(err)
+		w.rec = append(w.rec, value...)
+	}
+	w.sor = false
+	w.col++
+	if len(w.rec) >= w.b.Size() { // huge record
+		w.writeRecord()
+	}
+	return w.err == nil
+}
+
+// appendSep appends the separator to the current record.
+func (w *Writer) appendSep() {
+	if w.SepString != "" {
+		w.rec = append(w.rec, w.SepString...)
+	} else {
+		w.rec = append(w.rec, w.sep)
+	}
+}
+
+// containsSep tells if value contains SepString or would form one with an adjacent separator
+// (when it starts with a proper suffix or ends with a proper prefix of SepString, like "a|" with "||").
+func (w *Writer) containsSep(value []byte) bool {
+	v := bytesString(value)
+	if strings.Contains(v, w.SepString) {
+		return true
+	}
+	for i := 1; i < len(w.SepString); i++ {
+		if strings.HasSuffix(v, w.SepString[:i]) || strings.HasPrefix(v, w.SepString[i:]) {
+			return true
 		}
 	}
+	return false
+}
+
+// forceQuotes tells if value must be quoted even if it contains no sep, quote or newline.
+func (w *Writer) forceQuotes(value []byte) bool {
+	if w.QuoteEmpty && len(value) == 0 {
+		return true
+	} else if w.SepString != "" && w.containsSep(value) {
+		return true
+	}
+	switch w.Quoting {
+	case QuoteAll:
+		return true
+	case QuoteNonNumeric:
+		if isNum, _ := IsNumber(value); !isNum {
+			return true
+		}
+	}
+	if w.QuoteSpaces && len(value) > 0 {
+		first, last := value[0], value[len(value)-1]
+		return first == ' ' || first == '\t' || last == ' ' || last == '\t'
+	}
+	return false
+}
+
+// appendFloat formats f with the format of the current column.
+func (w *Writer) appendFloat(f float64, bitSize int) []byte {
+	nf := w.Float
+	if w.col < len(w.ColumnFloats) && w.ColumnFloats[w.col] != nil {
+		nf = w.ColumnFloats[w.col]
+	}
+	if nf == nil {
+		w.nb = strconv.AppendFloat(w.nb[:0], f, 'f', -1, bitSize)
+		return w.nb
+	}
+	verb := nf.Verb
+	if verb == 0 {
+		verb = 'f'
+	}
+	b := strconv.AppendFloat(w.nb[:0], f, verb, nf.Prec, bitSize)
+	w.nb = b
+	if nf.DecimalSep != 0 && nf.DecimalSep != '.' {
+		if i := bytes.IndexByte(b, '.'); i >= 0 {
+			b[i] = nf.DecimalSep
+		}
+	}
+	return b
+}
+
+// timeFormat returns the time format of the current column (nil when not specified).
+func (w *Writer) timeFormat() *TimeFormat {
+	if w.col < len(w.ColumnTimes) && w.ColumnTimes[w.col] != nil {
+		return w.ColumnTimes[w.col]
+	}
+	return w.Time
+}
+
+// appendTime formats t with tf.
+func (w *Writer) appendTime(t time.Time, tf *TimeFormat) []byte {
+	layout := tf.Layout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	if tf.Location != nil {
+		t = t.In(tf.Location)
+	}
+	w.nb = t.AppendFormat(w.nb[:0], layout)
+	return w.nb
+}
+
+// writeEscaped encloses value between quotes if it contains sep, quote or newline
+// and escapes quote, escape character, \n and \r with the escape character (MySQL/Hive style).
+func (w *Writer) writeEscaped(value []byte) {
+	q := w.Quote
+	if q == 0 {
+		q = '"'
+	}
+	quoted := w.forceQuotes(value)
+	for _, c := range value {
+		if c == q || c == '\r' || c == '\n' || c == w.sep && w.SepString == "" {
+			quoted = true
+			break
+		}
+	}
+	if quoted {
+		w.rec = append(w.rec, q)
+	}
+	last := 0
+	for i, c := range value {
+		var e byte
+		switch c {
+		case q, w.Escape:
+			e = c
+		case '\n':
+			e = 'n'
+		case '\r':
+			e = 'r'
+		default:
+			continue
+		}
+		w.rec = append(w.rec, value[last:i]...)
+		w.rec = append(w.rec, w.Escape)
+		w.rec = append(w.rec, e)
+		last = i + 1
+	}
+	w.rec = append(w.rec, value[last:]...)
+	if quoted {
+		w.rec = append(w.rec, q)
+	}
+}
+
+// SetColumnEncoder registers a function transforming the values of the column col (first is 0) before they are written,
+// like masking, hashing or unit conversion (nulls are not transformed).
+// The encoder is given a copy of the value that it may modify in place (but not retain).
+// An error is reported by Err (wrapped with the column index). A nil encoder unregisters the current one.
+func (w *Writer) SetColumnEncoder(col int, encoder func([]byte) ([]byte, error)) {
+	for len(w.encoders) <= col {
+		w.encoders = append(w.encoders, nil)
+	}
+	w.encoders[col] = encoder
+}
+
+// WriteNull writes the Null token (never quoted).
+func (w *Writer) WriteNull() bool {
+	if w.err != nil {
+		return false
+	}
+	if w.widths != nil {
+		return w.writeFixed(stringBytes(w.Null))
+	}
+	if !w.sor {
+		w.appendSep()
+	}
+	w.rec = append(w.rec, w.Null...)
 	w.sor = false
+	w.col++
 	return w.err == nil
 }
 
 // EndOfRecord tells when a line break must be inserted.
 func (w *Writer) EndOfRecord() {
+	for ; w.col < len(w.widths); w.col++ { // missing fixed-width values
+		w.rec = appendSpaces(w.rec, w.widths[w.col])
+	}
 	if w.UseCRLF {
-		w.setErr(w.b.WriteByte('\r'))
+		w.rec = append(w.rec, '\r')
 	}
-	w.setErr(w.b.WriteByte('\n'))
+	w.rec = append(w.rec, '\n')
+	w.writeRecord()
 	w.sor = true
+	w.col = 0
+	if w.eor != nil {
+		w.eor()
+	}
 }
 
-// Flush ensures the writer's buffer is flushed.
+// Flush ensures the writer's buffer is flushed (including the current record even if not ended).
 func (w *Writer) Flush() {
+	w.writeRecord()
 	w.setErr(w.b.Flush())
 }
 
+// writeRecord writes the pending (maybe partial) record to the underlying buffer.
+func (w *Writer) writeRecord() {
+	if len(w.rec) == 0 {
+		return
+	}
+	_, err := w.b.Write(w.rec)
+	w.setErr(err)
+	w.rec = w.rec[:0]
+}
+
 // Err returns the first error that was encountered by the Writer.
 func (w *Writer) Err() error {
 	return w.err
@@ -6,14 +6,24 @@ package yacr_test
 
 import (
 	"bytes"
+	"database/sql"
 	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	. "github.com/gwenn/yacr"
 )
 
-func writeRow(w *Writer, row []string) {
+type rowWriter interface {
+	WriteString(value string) bool
+	EndOfRecord()
+}
+
+func writeRow(w rowWriter, row []string) {
 	for _, field := range row {
 		if !w.WriteString(field) {
 			break
@@ -24,9 +34,11 @@ func writeRow(w *Writer, row []string) {
 
 // Stolen/adapted from $GOROOT/src/pkg/encoding/csv/writer_test.go
 var writeTests = []struct {
-	Input   [][]string
-	Output  string
-	UseCRLF bool
+	Input       [][]string
+	Output      string
+	UseCRLF     bool
+	QuoteSpaces bool
+	Quoting     Quoting
 }{
 	{Input: [][]string{{"abc"}}, Output: "abc\n"},
 	{Input: [][]string{{"abc"}}, Output: "abc\r\n", UseCRLF: true},
@@ -43,6 +55,10 @@ var writeTests = []struct {
 	{Input: [][]string{{"abc\rdef"}}, Output: "\"abc\rdef\"\n", UseCRLF: false},
 	{Input: [][]string{{"a", "b,\n", "c\"d"}}, Output: "a,\"b,\n\",\"c\"\"d\"\n"},
 	{Input: [][]string{{"à", "é", "è", "ù"}}, Output: "à,é,è,ù\n"},
+	{Input: [][]string{{" abc", "def\t", "g h", ""}}, Output: "\" abc\",\"def\t\",g h,\n", QuoteSpaces: true},
+	{Input: [][]string{{" a\"b"}}, Output: "\" a\"\"b\"\n", QuoteSpaces: true},
+	{Input: [][]string{{"a", "1", ""}}, Output: "\"a\",\"1\",\"\"\n", Quoting: QuoteAll},
+	{Input: [][]string{{"a", "-1", "3.14", "1e3", "", "x,y"}}, Output: "\"a\",-1,3.14,1e3,\"\",\"x,y\"\n", Quoting: QuoteNonNumeric},
 }
 
 func TestWrite(t *testing.T) {
@@ -50,6 +66,8 @@ func TestWrite(t *testing.T) {
 		b := &bytes.Buffer{}
 		f := DefaultWriter(b)
 		f.UseCRLF = tt.UseCRLF
+		f.QuoteSpaces = tt.QuoteSpaces
+		f.Quoting = tt.Quoting
 		for _, row := range tt.Input {
 			writeRow(f, row)
 		}
@@ -65,6 +83,159 @@ func TestWrite(t *testing.T) {
 	}
 }
 
+func TestEscape(t *testing.T) {
+	rows := [][]string{{`a\b`, "c,d", "e\nf", ""}, {"g\r", "h"}, {"i\r\nj,"}}
+	b := &bytes.Buffer{}
+	w := NewWriter(b, ',', false)
+	w.Escape = '\\'
+	for _, row := range rows {
+		writeRow(w, row)
+	}
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if want := "a\\\\b,c\\,d,e\\\nf,\ng\\\r,h\ni\\\r\\\nj\\,\n"; b.String() != want {
+		t.Errorf("out=%q want %q", b.String(), want)
+	}
+
+	r := NewReader(b, ',', false, false)
+	r.Escape = '\\'
+	for _, row := range rows {
+		if record, err := r.Record(); err != nil {
+			t.Errorf("Unexpected error: %s\n", err)
+		} else if !reflect.DeepEqual(record, row) {
+			t.Errorf("got %q; want %q", record, row)
+		}
+	}
+	if r.LineNumber() != 6 {
+		t.Errorf("got line %d; want %d", r.LineNumber(), 6)
+	}
+}
+
+func TestSingleQuoteWrite(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewWriter(b, ';', true)
+	w.Quote = '\''
+	writeRow(w, []string{"a;b", "it's", `"c"`, "d\ne"})
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if want := "'a;b';'it''s';\"c\";'d\ne'\n"; b.String() != want {
+		t.Errorf("out=%q want %q", b.String(), want)
+	}
+}
+
+func TestEscapeQuoted(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	w.Escape = '\\'
+	writeRow(w, []string{`a"b`, `c\d`, "e\r\nf", "g,h", "i"})
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if want := `"a\"b",c\\d,"e\r\nf","g,h",i` + "\n"; b.String() != want {
+		t.Errorf("out=%q want %q", b.String(), want)
+	}
+
+	b.WriteString(`"j\,k","l""m",n` + "\r\n")
+	r := DefaultReader(b)
+	r.Escape = '\\'
+	for _, row := range [][]string{{`a"b`, `c\d`, "e\r\nf", "g,h", "i"}, {"j,k", `l"m`, "n"}} {
+		if record, err := r.Record(); err != nil {
+			t.Errorf("Unexpected error: %s\n", err)
+		} else if !reflect.DeepEqual(record, row) {
+			t.Errorf("got %q; want %q", record, row)
+		}
+	}
+	r = DefaultReader(strings.NewReader(`"a\"b`))
+	r.Escape = '\\'
+	if _, err := r.Record(); err == nil {
+		t.Error("error expected for a non-terminated quoted field")
+	}
+}
+
+func TestWriteNull(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	w.QuoteEmpty = true
+	w.WriteRecord("a", "", nil, 1)
+	w.Null = `\N`
+	w.WriteRecord(nil, "")
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if want := "a,\"\",,1\n\\N,\"\"\n"; b.String() != want {
+		t.Errorf("out=%q want %q", b.String(), want)
+	}
+}
+
+func TestFloatFormat(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := NewWriter(b, ';', true)
+	w.Float = &NumberFormat{Prec: 2}
+	w.ColumnFloats = []*NumberFormat{nil, {Verb: 'e', Prec: 1}, {Prec: 1, DecimalSep: ','}}
+	w.WriteRecord(3.14159, 1234.5, float32(2.25), 1.0)
+	w.Float = nil
+	w.WriteRecord(0.5, 0.1)
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if want := "3.14;1.2e+03;2,2;1.00\n0.5;1.0e-01\n"; b.String() != want {
+		t.Errorf("out=%q want %q", b.String(), want)
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	d := time.Date(2024, 2, 29, 23, 30, 0, 0, time.UTC)
+	w.WriteRecord(d)
+	w.Time = &TimeFormat{Location: time.FixedZone("", 3600)}
+	w.ColumnTimes = []*TimeFormat{{Layout: "2006-01-02"}}
+	w.WriteRecord(d, d)
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if want := "2024-02-29T23:30:00Z\n2024-02-29,2024-03-01T00:30:00+01:00\n"; b.String() != want {
+		t.Errorf("out=%q want %q", b.String(), want)
+	}
+}
+
+func TestWriteBool(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	w.WriteRecord(true, false)
+	w.Bools = &BoolTokens{True: []string{"Y"}, False: []string{"N"}}
+	w.WriteRecord(true, false)
+	w.Flush()
+	if want := "true,false\nY,N\n"; b.String() != want {
+		t.Errorf("out=%q want %q", b.String(), want)
+	}
+}
+
+func TestWritePartialRecord(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	w.WriteString("a")
+	w.Flush()
+	if b.String() != "a" {
+		t.Errorf("out=%q want %q", b.String(), "a")
+	}
+	huge := strings.Repeat("x", 10000)
+	w.WriteString(huge)
+	w.EndOfRecord()
+	w.Flush()
+	if want := "a," + huge + "\n"; b.String() != want {
+		t.Errorf("got %d bytes; want %d", b.Len(), len(want))
+	}
+}
+
 type errorWriter struct{}
 
 func (e errorWriter) Write(_ []byte) (int, error) {
@@ -98,6 +269,8 @@ var writeRecordTests = []struct {
 }{
 	{Input: []interface{}{"abc"}, Output: "abc\n"},
 	{Input: []interface{}{nil, "nil", 123, 3.14, time.Unix(0, 0).UTC()}, Output: ",nil,123,3.14,1970-01-01T00:00:00Z\n"},
+	{Input: []interface{}{true, []byte("a,b"), time.Monday, net.IPv4(127, 0, 0, 1), uint8(7)}, Output: "true,\"a,b\",Monday,127.0.0.1,7\n"},
+	{Input: []interface{}{(*time.Duration)(nil), time.Second, (*time.Month)(nil)}, Output: ",1s,\n"},
 }
 
 func TestWriteRecord(t *testing.T) {
@@ -116,3 +289,195 @@ func TestWriteRecord(t *testing.T) {
 		}
 	}
 }
+
+func TestSetHeader(t *testing.T) {
+	type person struct {
+		Name   string
+		Age    int    `csv:"age"`
+		Secret string `csv:"-"`
+		email  string
+	}
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	if w.WriteMap(map[string]interface{}{"Name": "bob"}) || w.Err() == nil {
+		t.Error("error expected without header")
+	}
+	w = DefaultWriter(b)
+	w.SetHeader([]string{"age", "Name", "city"})
+	w.WriteRecordByName("Name", "bob", "age", 42)
+	w.WriteMap(map[string]interface{}{"city": "Paris", "Name": "alice"})
+	w.WriteStruct(&person{Name: "eve", Age: 7, Secret: "x", email: "y"})
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "age,Name,city\n42,bob,\n,alice,Paris\n7,eve,\n"; b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+
+	b.Reset()
+	w = DefaultWriter(b)
+	w.SetHeader([]string{"Name"})
+	if w.WriteMap(map[string]interface{}{"Name": "bob", "zip": 1}) || w.Err() == nil {
+		t.Error("error expected for unknown column")
+	}
+	b.Reset()
+	w = DefaultWriter(b)
+	w.DropUnknown = true
+	w.SetHeader([]string{"Name"})
+	w.WriteStruct(person{Name: "bob", Age: 42})
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	} else if want := "Name\nbob\n"; b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+}
+
+func TestWriteStructs(t *testing.T) {
+	type point struct {
+		X, Y  float64
+		Label string `csv:"label,omitempty"`
+	}
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	w.WriteStructs([]*point{{1, 2, "a,b"}, nil, {X: 3.5}})
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	} else if want := "X,Y,label\n1,2,\"a,b\"\n3.5,0,\n"; b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+	if w = DefaultWriter(b); w.WriteStructs([]int{1}) || w.Err() == nil {
+		t.Error("error expected for a slice of non-struct")
+	}
+}
+
+func TestSetColumnEncoder(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	w.SetColumnEncoder(1, func(v []byte) ([]byte, error) { return bytes.Repeat([]byte{'*'}, len(v)), nil })
+	w.WriteRecord("alice", "secret", nil)
+	w.WriteRecord("bob", nil, "pwd")
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	} else if want := "alice,******,\nbob,,pwd\n"; b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+	w.SetColumnEncoder(1, func(v []byte) ([]byte, error) { // in place
+		for i := range v {
+			v[i] = '*'
+		}
+		return v, nil
+	})
+	b.Reset()
+	w.WriteString("carol")
+	w.WriteString("secret") // read-only memory
+	w.EndOfRecord()
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	} else if want := "carol,******\n"; b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+	w.SetColumnEncoder(0, func(v []byte) ([]byte, error) { return nil, errors.New("boom") })
+	if w.WriteRecord("x") || w.Err() == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestWriteHeaderFromStruct(t *testing.T) {
+	type row struct {
+		B string `csv:"b"`
+		A int    `csv:"a"`
+	}
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	w.WriteStruct(row{"x", 1}) // field order without header
+	w.WriteHeaderFromStruct(&row{})
+	w.WriteStruct(&row{"y", 2})
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	} else if want := "x,1\nb,a\ny,2\n"; b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+	if w.WriteHeaderFromStruct(1) || w.Err() == nil {
+		t.Error("error expected for a non-struct")
+	}
+}
+
+func TestSepString(t *testing.T) {
+	rows := [][]string{{"a|b", "c||d", `e"f`, ""}, {"g\nh", "i,j"}, {"l|", "m", "|n"}}
+	b := &bytes.Buffer{}
+	w := DefaultWriter(b)
+	w.SepString = "||"
+	for _, row := range rows {
+		writeRow(w, row)
+	}
+	w.WriteNull()
+	w.WriteString("k")
+	w.EndOfRecord()
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	if want := "a|b||\"c||d\"||\"e\"\"f\"||\n\"g\nh\"||i,j\n\"l|\"||m||\"|n\"\n||k\n"; b.String() != want {
+		t.Errorf("out=%q want %q", b.String(), want)
+	}
+
+	r := DefaultReader(b)
+	r.SepString = "||"
+	r.Buffer(make([]byte, 0, 16), 1<<20) // small buffer to check partial separators
+	for _, row := range append(rows, []string{"", "k"}) {
+		if record, err := r.Record(); err != nil {
+			t.Errorf("Unexpected error: %s\n", err)
+		} else if !reflect.DeepEqual(record, row) {
+			t.Errorf("got %q; want %q", record, row)
+		}
+	}
+	if _, err := r.Record(); err != io.EOF {
+		t.Errorf("got %v; want EOF", err)
+	}
+
+	w = NewWriter(b, ',', false)
+	w.SepString = "~|~"
+	if w.WriteString("a~|~b") || w.Err() != ErrSeparator {
+		t.Errorf("got %v; want %v", w.Err(), ErrSeparator)
+	}
+	w = NewWriter(b, ',', false)
+	w.SepString = "~|~"
+	if w.WriteString("a~|") || w.Err() != ErrSeparator {
+		t.Errorf("got %v; want %v", w.Err(), ErrSeparator)
+	}
+}
+
+func TestNulls(t *testing.T) {
+	n := 42
+	var nilInt *int
+	b := &bytes.Buffer{}
+	w := NewWriter(b, '\t', false)
+	w.Null = `\N`
+	w.WriteRecord(sql.NullString{String: "a", Valid: true}, sql.NullInt64{}, &n, nilInt, (*net.IP)(nil), nil)
+	w.Flush()
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	} else if want := "a\t\\N\t42\t\\N\t\\N\t\\N\n"; b.String() != want {
+		t.Errorf("got %q; want %q", b.String(), want)
+	}
+
+	r := NewReader(b, '\t', false, false)
+	r.Nulls = []string{`\N`}
+	var s sql.NullString
+	i := sql.NullInt64{Int64: 1, Valid: true}
+	var p1, p2 *int
+	var d *time.Time
+	var str string
+	if _, err := r.ScanRecord(&s, &i, &p1, &p2, &d, &str); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Valid || s.String != "a" || i.Valid || p1 == nil || *p1 != 42 || p2 != nil || d != nil || str != `\N` {
+		t.Errorf("got %v, %v, %v, %v, %v, %q", s, i, p1, p2, d, str)
+	}
+}
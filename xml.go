@@ -0,0 +1,158 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// XMLWriter provides an interface for writing records as simple XML
+// (<table><row><field name="...">...</field>...</row>...</table>).
+// Successive calls to the Write method will emit one field element.
+// The EndOfRecord method tells when the current row element must be closed.
+type XMLWriter struct {
+	b       *bufio.Writer
+	names   []string // field names (may be nil)
+	col     int      // index of the next field in the current record
+	started bool     // true when the root element has been opened
+	err     error    // sticky error.
+
+	RootElement  string // name of the root element ("table" by default)
+	RowElement   string // name of the record elements ("row" by default)
+	FieldElement string // name of the field elements ("field" by default). When empty, the field name is used instead (unless it is not a valid XML name).
+	NameAttr     string // name of the attribute holding the field name ("name" by default). When empty, no attribute is written.
+}
+
+// NewXMLWriter returns a new XML writer.
+// names are used to label fields (see FieldElement and NameAttr), they may be nil.
+func NewXMLWriter(w io.Writer, names []string) *XMLWriter {
+	return &XMLWriter{b: bufio.NewWriter(w), names: names,
+		RootElement: "table", RowElement: "row", FieldElement: "field", NameAttr: "name"}
+}
+
+// Write writes value as the content of one field element.
+func (w *XMLWriter) Write(value []byte) bool {
+	if w.err != nil {
+		return false
+	}
+	w.start()
+	if w.col == 0 {
+		w.startTag(w.RowElement, "")
+	}
+	var name string
+	if w.col < len(w.names) {
+		name = w.names[w.col]
+	}
+	elt := w.FieldElement
+	if elt == "" && isXMLName(name) {
+		elt = name
+		w.startTag(elt, "")
+	} else {
+		if elt == "" {
+			elt = "field"
+		}
+		w.startTag(elt, name)
+	}
+	w.setErr(xml.EscapeText(w.b, value))
+	w.endTag(elt)
+	w.col++
+	return w.err == nil
+}
+
+// WriteString writes value as the content of one field element.
+func (w *XMLWriter) WriteString(value string) bool {
+	return w.Write([]byte(value))
+}
+
+// EndOfRecord tells when the current row element must be closed.
+func (w *XMLWriter) EndOfRecord() {
+	if w.col == 0 { // empty record
+		w.Write([]byte{})
+	}
+	w.endTag(w.RowElement)
+	w.setErr(w.b.WriteByte('\n'))
+	w.col = 0
+}
+
+// Flush ensures the writer's buffer is flushed.
+func (w *XMLWriter) Flush() {
+	w.setErr(w.b.Flush())
+}
+
+// Close closes the root element and flushes the writer's buffer.
+// It does not close the underlying writer.
+func (w *XMLWriter) Close() error {
+	if w.col != 0 {
+		w.EndOfRecord()
+	}
+	w.start()
+	w.endTag(w.RootElement)
+	w.setErr(w.b.WriteByte('\n'))
+	w.Flush()
+	return w.err
+}
+
+// Err returns the first error that was encountered by the XMLWriter.
+func (w *XMLWriter) Err() error {
+	return w.err
+}
+
+func (w *XMLWriter) start() {
+	if w.started {
+		return
+	}
+	w.startTag(w.RootElement, "")
+	w.setErr(w.b.WriteByte('\n'))
+	w.started = true
+}
+
+func (w *XMLWriter) startTag(elt, name string) {
+	w.setErr(w.b.WriteByte('<'))
+	_, err := w.b.WriteString(elt)
+	w.setErr(err)
+	if name != "" && w.NameAttr != "" {
+		w.setErr(w.b.WriteByte(' '))
+		_, err = w.b.WriteString(w.NameAttr)
+		w.setErr(err)
+		_, err = w.b.WriteString(`="`)
+		w.setErr(err)
+		w.setErr(xml.EscapeText(w.b, []byte(name)))
+		w.setErr(w.b.WriteByte('"'))
+	}
+	w.setErr(w.b.WriteByte('>'))
+}
+
+func (w *XMLWriter) endTag(elt string) {
+	_, err := w.b.WriteString("</")
+	w.setErr(err)
+	_, err = w.b.WriteString(elt)
+	w.setErr(err)
+	w.setErr(w.b.WriteByte('>'))
+}
+
+// isXMLName tells if name can be used as an element name
+// (a letter or an underscore followed by letters, digits, '-', '.' or '_', not starting with "xml").
+func isXMLName(name string) bool {
+	if name == "" || len(name) >= 3 && strings.EqualFold(name[:3], "xml") {
+		return false
+	}
+	for i, r := range name {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r) && r != '-' && r != '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// setErr records the first error encountered.
+func (w *XMLWriter) setErr(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
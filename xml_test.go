@@ -0,0 +1,69 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package yacr_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/gwenn/yacr"
+)
+
+var xmlTests = []struct {
+	Name           string
+	Names          []string
+	NoFieldElement bool // field names used as element names
+	Input          [][]string
+	Output         string
+}{
+	{
+		Name:   "Empty",
+		Output: "<table>\n</table>\n",
+	},
+	{
+		Name:   "Simple",
+		Names:  []string{"a", "b"},
+		Input:  [][]string{{"1", "2"}, {"x<y", "\"&\""}},
+		Output: "<table>\n<row><field name=\"a\">1</field><field name=\"b\">2</field></row>\n<row><field name=\"a\">x&lt;y</field><field name=\"b\">&#34;&amp;&#34;</field></row>\n</table>\n",
+	},
+	{
+		Name:   "NoNames",
+		Input:  [][]string{{"1", "2"}},
+		Output: "<table>\n<row><field>1</field><field>2</field></row>\n</table>\n",
+	},
+	{
+		Name:           "NameAsElement",
+		Names:          []string{"id"},
+		NoFieldElement: true,
+		Input:          [][]string{{"1", "2"}},
+		Output:         "<table>\n<row><id>1</id><field>2</field></row>\n</table>\n",
+	},
+	{
+		Name:           "InvalidNameAsElement",
+		Names:          []string{"first name", "2nd", "xmlns", "<b>", "é_1"},
+		NoFieldElement: true,
+		Input:          [][]string{{"a", "b", "c", "d", "e"}},
+		Output:         "<table>\n<row><field name=\"first name\">a</field><field name=\"2nd\">b</field><field name=\"xmlns\">c</field><field name=\"&lt;b&gt;\">d</field><é_1>e</é_1></row>\n</table>\n",
+	},
+}
+
+func TestXMLWriter(t *testing.T) {
+	for _, tt := range xmlTests {
+		b := &bytes.Buffer{}
+		w := NewXMLWriter(b, tt.Names)
+		if tt.NoFieldElement {
+			w.FieldElement = ""
+		}
+		for _, row := range tt.Input {
+			writeRow(w, row)
+		}
+		if err := w.Close(); err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.Name, err)
+		}
+		if out := b.String(); out != tt.Output {
+			t.Errorf("%s: out=%q want %q", tt.Name, out, tt.Output)
+		}
+	}
+}